@@ -14,8 +14,13 @@ type Attendee struct {
 }
 
 func (a Attendee) String() string {
-	return fmt.Sprintf("Type: %v, E-mail: %s, ResponseStatus: %v, TimeSlot: %s",
-		a.Type, a.EmailAddress.String(), a.ResponseStatus, a.ProposedNewTime.String())
+	var attendeeType AttendeeType
+	if a.Type != nil {
+		attendeeType = *a.Type
+	}
+	// Hint: %v prints <nil> for the optional fields that are not set
+	return fmt.Sprintf("Type: %s, E-mail: %v, ResponseStatus: %v, TimeSlot: %v",
+		attendeeType, a.EmailAddress, a.ResponseStatus, a.ProposedNewTime)
 }
 
 // Equal compares the Attendee to the other Attendee and returns true
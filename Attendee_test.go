@@ -63,8 +63,8 @@ func TestAttendee_UnmarshalJSON(t *testing.T) {
 			name: "All good",
 			a:    &Attendee{},
 			args: args{data: []byte(
-				`{	"Type" : "organizer", 
-					"Status": {"response" : "accepted", "time" : "` + time.Now().Format(time.RFC3339Nano) + `"},
+				`{	"type" : "required",
+					"status": {"response" : "accepted", "time" : "` + time.Now().Format(time.RFC3339Nano) + `"},
 					"emailAddress" : {
 						"name" : "TestUserName",
 						"address": "TestUserName@contoso.com"
@@ -101,7 +101,8 @@ func TestAttendee_String(t *testing.T) {
 		{
 			name: "Test String-func",
 			a:    testAttendee1,
-			want: "Name: Testname, Type: attendee, E-mail: testname@contoso.com, ResponseStatus: Response: accepted, Time: " + testAttendee1.ResponseStatus.Time.Format(time.RFC3339Nano),
+			want: "Type: required, E-mail: Testname<testname@contoso.com>, ResponseStatus: Response: accepted, Time: " +
+				testAttendee1.ResponseStatus.Time.Format(time.RFC3339Nano) + ", TimeSlot: <nil>",
 		},
 	}
 	for _, tt := range tests {
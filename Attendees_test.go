@@ -14,10 +14,10 @@ func TestAttendees_String(t *testing.T) {
 		{
 			name: "test eq",
 			a:    Attendees{testAttendee1, testAttendee2},
-			want: "Attendees(Name: Testname, Type: attendee, E-mail: testname@contoso.com, ResponseStatus: Response: accepted, Time: " +
-				testAttendee1.ResponseStatus.Time.Format(time.RFC3339Nano) +
-				" | Name: Testuser, Type: attendee, E-mail: testuser@contoso.com, ResponseStatus: Response: declined, Time: " +
-				testAttendee2.ResponseStatus.Time.Format(time.RFC3339Nano) + ")",
+			want: "Attendees(Type: required, E-mail: Testname<testname@contoso.com>, ResponseStatus: Response: accepted, Time: " +
+				testAttendee1.ResponseStatus.Time.Format(time.RFC3339Nano) + ", TimeSlot: <nil>" +
+				" | Type: required, E-mail: Testuser<testuser@contoso.com>, ResponseStatus: Response: declined, Time: " +
+				testAttendee2.ResponseStatus.Time.Format(time.RFC3339Nano) + ", TimeSlot: <nil>)",
 		},
 	}
 	for _, tt := range tests {
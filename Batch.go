@@ -0,0 +1,376 @@
+package msgraph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// MaxBatchSize is the maximum number of requests within a single JSON $batch API-call. A BatchRequest
+// with more requests is automatically split into multiple API-calls.
+const MaxBatchSize int = 20
+
+// BatchRequest combines multiple API-calls into JSON $batch requests to reduce the number of round trips.
+// Create it with GraphClient.NewBatch, add requests with e.g. GetUser or ListCalendars and run them with
+// Execute. The results are written into the given pointers, errors are available per request with BatchItem.Err.
+//
+// See https://docs.microsoft.com/en-us/graph/json-batching
+type BatchRequest struct {
+	graphClient *GraphClient
+	items       []*BatchItem
+}
+
+// BatchItem represents a single request of a BatchRequest and holds its result after BatchRequest.Execute
+type BatchItem struct {
+	ID         string      // the ID of the request within the batch, assigned automatically
+	Method     string      // the http method, e.g. http.MethodGet
+	URL        string      // the relative URL including the query parameters, e.g. /users/alice@contoso.com
+	StatusCode int         // the HTTP StatusCode of the response, available after Execute
+	Header     http.Header // the response headers, available after Execute
+
+	header    http.Header  // request headers, e.g. ConsistencyLevel
+	body      interface{}  // request body, json-marshalled, may be nil
+	dependsOn []*BatchItem // requests that must succeed before this request is executed
+	v         interface{}  // json-unmarshal target of the response body, may be nil
+	finish    func()       // called after v has been successfully unmarshalled, e.g. to set the graphClient
+	executed  bool         // true as soon as a response for this request has been processed
+	err       error        // the error of this request, nil on success
+}
+
+// NewBatch creates a new, empty BatchRequest for this GraphClient
+func (g *GraphClient) NewBatch() *BatchRequest {
+	return &BatchRequest{graphClient: g}
+}
+
+// DependsOn declares that this request must only be executed after all given requests of the same
+// BatchRequest succeeded. The given requests must have been added to the BatchRequest before this one.
+// If a dependency fails, this request fails with a GraphError with StatusCode 424 (Failed Dependency).
+func (b *BatchItem) DependsOn(items ...*BatchItem) *BatchItem {
+	b.dependsOn = append(b.dependsOn, items...)
+	return b
+}
+
+// Err returns the error of this request after BatchRequest.Execute, which is a *GraphError if the
+// ms graph API returned an error for this request. Returns nil on success.
+func (b *BatchItem) Err() error {
+	return b.err
+}
+
+// Len returns the number of requests added to the BatchRequest
+func (b *BatchRequest) Len() int {
+	return len(b.items)
+}
+
+// add adds a request for any API-call. Parameter resource is the API-call without the API version, e.g.
+// "/users/alice@contoso.com", reqParams may contain query options and headers, body is json-marshalled and
+// may be nil and v is the json-unmarshal target of the response and may be nil. All requests are executed
+// with the API version of the GraphClient, the one of reqParams is ignored.
+func (b *BatchRequest) add(httpMethod, resource string, reqParams getRequestParams, body interface{}, v interface{}) *BatchItem {
+	reqURL := resource
+	if query := encodeQueryParams(httpMethod, reqParams); query != "" {
+		reqURL += "?" + query
+	}
+	item := &BatchItem{
+		ID:     strconv.Itoa(len(b.items) + 1),
+		Method: httpMethod,
+		URL:    reqURL,
+		header: reqParams.Headers(),
+		body:   body,
+		v:      v,
+	}
+	b.items = append(b.items, item)
+	return item
+}
+
+// Get adds a GET request for any resource without a typed helper, e.g. "/users/alice@contoso.com/manager".
+// Parameter resource is the API-call without the API version, the result is written into v, which may be nil.
+// The API version of the GraphClient is used, GetWithAPIVersion is ignored.
+func (b *BatchRequest) Get(resource string, v interface{}, opts ...GetQueryOption) *BatchItem {
+	return b.add(http.MethodGet, resource, compileGetQueryOptions(opts), nil, v)
+}
+
+// List adds a GET request for any collection without a typed helper, e.g. "/users/alice@contoso.com/memberOf".
+// Parameter resource is the API-call without the API version, the entries of the value of all pages are
+// written into v, which must be a pointer to a slice. The API version of the GraphClient is used,
+// ListWithAPIVersion is ignored.
+func (b *BatchRequest) List(resource string, v interface{}, opts ...ListQueryOption) *BatchItem {
+	marsh := struct {
+		Value interface{} `json:"value"`
+	}{Value: v}
+	return b.add(http.MethodGet, resource, compileListQueryOptions(opts), nil, &marsh)
+}
+
+// Create adds a POST request for any collection without a typed helper, e.g. "/groups". Parameter resource is
+// the API-call without the API version, body is json-marshalled and the created resource is written into v,
+// which may be nil. The API version of the GraphClient is used, CreateWithAPIVersion is ignored.
+func (b *BatchRequest) Create(resource string, body, v interface{}, opts ...CreateQueryOption) *BatchItem {
+	return b.add(http.MethodPost, resource, compileCreateQueryOptions(opts), body, v)
+}
+
+// Update adds a PATCH request for any resource, e.g. "/groups/{id}". Parameter resource is the API-call
+// without the API version, body is json-marshalled and contains the properties to update. The API version
+// of the GraphClient is used, UpdateWithAPIVersion is ignored.
+func (b *BatchRequest) Update(resource string, body interface{}, opts ...UpdateQueryOption) *BatchItem {
+	return b.add(http.MethodPatch, resource, compileUpdateQueryOptions(opts), body, nil)
+}
+
+// Delete adds a DELETE request for any resource, e.g. "/groups/{id}". Parameter resource is the API-call
+// without the API version. The API version of the GraphClient is used, DeleteWithAPIVersion is ignored.
+func (b *BatchRequest) Delete(resource string, opts ...DeleteQueryOption) *BatchItem {
+	return b.add(http.MethodDelete, resource, compileDeleteQueryOptions(opts), nil, nil)
+}
+
+// GetUser adds a request to get the user identified by either the given ID or userPrincipalName, the
+// result is written into user.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+//
+// Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user_get
+func (b *BatchRequest) GetUser(identifier string, user *User, opts ...GetQueryOption) *BatchItem {
+	item := b.add(http.MethodGet, fmt.Sprintf("/users/%v", identifier), compileGetQueryOptions(opts), nil, user)
+	item.finish = func() { user.setGraphClient(b.graphClient) }
+	return item
+}
+
+// GetGroup adds a request to get the group identified by the given groupID, the result is written into group.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+//
+// Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/group_get
+func (b *BatchRequest) GetGroup(groupID string, group *Group, opts ...GetQueryOption) *BatchItem {
+	item := b.add(http.MethodGet, fmt.Sprintf("/groups/%v", groupID), compileGetQueryOptions(opts), nil, group)
+	item.finish = func() { group.setGraphClient(b.graphClient) }
+	return item
+}
+
+// CreateUser adds a request to create a new user, the created user is written into user.
+//
+// Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user-post-users
+func (b *BatchRequest) CreateUser(userInput User, user *User, opts ...CreateQueryOption) *BatchItem {
+	item := b.add(http.MethodPost, "/users", compileCreateQueryOptions(opts), userInput, user)
+	item.finish = func() { user.setGraphClient(b.graphClient) }
+	return item
+}
+
+// ListMembers adds a request to list the direct members of the given group, the result is written into users.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+//
+// See https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/group_list_members
+func (b *BatchRequest) ListMembers(group Group, users *Users, opts ...ListQueryOption) *BatchItem {
+	var marsh struct {
+		Users *Users `json:"value"`
+	}
+	marsh.Users = users
+	item := b.add(http.MethodGet, fmt.Sprintf("/groups/%v/members", group.ID), compileListQueryOptions(opts), nil, &marsh)
+	item.finish = func() { users.setGraphClient(b.graphClient) }
+	return item
+}
+
+// ListCalendars adds a request to list all calendars of the given user, the result is written into calendars.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+//
+// Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user_list_calendars
+func (b *BatchRequest) ListCalendars(user User, calendars *Calendars, opts ...ListQueryOption) *BatchItem {
+	var marsh struct {
+		Calendars *Calendars `json:"value"`
+	}
+	marsh.Calendars = calendars
+	item := b.add(http.MethodGet, fmt.Sprintf("/users/%v/calendars", user.ID), compileListQueryOptions(opts), nil, &marsh)
+	item.finish = func() { calendars.setGraphClient(b.graphClient) }
+	return item
+}
+
+// ListCalendarGroups adds a request to list all calendar groups of the given user, the result is written
+// into calendarGroups.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+//
+// Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user-list-calendargroups
+func (b *BatchRequest) ListCalendarGroups(user User, calendarGroups *CalendarGroups, opts ...ListQueryOption) *BatchItem {
+	var marsh struct {
+		CalendarGroups *CalendarGroups `json:"value"`
+	}
+	marsh.CalendarGroups = calendarGroups
+	item := b.add(http.MethodGet, fmt.Sprintf("/users/%v/calendarGroups", user.ID), compileListQueryOptions(opts), nil, &marsh)
+	item.finish = func() {
+		user.setGraphClient(b.graphClient)
+		calendarGroups.setGraphClient(b.graphClient, &user)
+	}
+	return item
+}
+
+// ListCategories adds a request to list all outlook categories of the given user, the result is written
+// into categories.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+func (b *BatchRequest) ListCategories(user User, categories *OutlookCategories, opts ...ListQueryOption) *BatchItem {
+	item := b.add(http.MethodGet, fmt.Sprintf("/users/%v/outlook/masterCategories", user.ID), compileListQueryOptions(opts), nil, categories)
+	item.finish = func() {
+		user.setGraphClient(b.graphClient)
+		categories.setGraphClient(&user)
+	}
+	return item
+}
+
+// batchRequestItem is the json representation of a single request within a $batch API-call
+type batchRequestItem struct {
+	ID        string            `json:"id"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      json.RawMessage   `json:"body,omitempty"`
+	DependsOn []string          `json:"dependsOn,omitempty"`
+}
+
+// batchResponseItem is the json representation of a single response within a $batch API-call
+type batchResponseItem struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// Execute runs all requests of the BatchRequest with as few $batch API-calls as possible, at most
+// MaxBatchSize requests per API-call. The results are written into the pointers given when adding the
+// requests, the error of each request is available with BatchItem.Err. List requests automatically load
+// all further pages.
+//
+// Returns an error if a $batch API-call itself fails, in that case the remaining requests are not executed.
+func (b *BatchRequest) Execute(ctx context.Context) error {
+	if b.graphClient == nil {
+		return ErrNotGraphClientSourced
+	}
+	positions := make(map[*BatchItem]int, len(b.items))
+	for idx, item := range b.items {
+		for _, dependency := range item.dependsOn {
+			if pos, ok := positions[dependency]; !ok || pos >= idx {
+				return fmt.Errorf("request %v depends on a request that has not been added to the batch before", item.ID)
+			}
+		}
+		positions[item] = idx
+	}
+
+	for start := 0; start < len(b.items); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(b.items) {
+			end = len(b.items)
+		}
+		if err := b.executeChunk(ctx, b.items[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executeChunk runs the given requests with a single $batch API-call. Requests depending on a failed
+// request of a previous chunk are not sent but fail immediately.
+func (b *BatchRequest) executeChunk(ctx context.Context, items []*BatchItem) error {
+	inChunk := make(map[*BatchItem]bool, len(items))
+	var requests []batchRequestItem
+	for _, item := range items {
+		inChunk[item] = true
+		req := batchRequestItem{ID: item.ID, Method: item.Method, URL: item.URL}
+		var failedDependency bool
+		for _, dependency := range item.dependsOn {
+			if inChunk[dependency] {
+				req.DependsOn = append(req.DependsOn, dependency.ID)
+			} else if dependency.err != nil {
+				failedDependency = true // executed in a previous chunk, hence already done
+			}
+		}
+		if failedDependency {
+			item.setFailedDependency()
+			continue
+		}
+		if item.body != nil {
+			bodyBytes, err := json.Marshal(item.body)
+			if err != nil {
+				return err
+			}
+			req.Body = bodyBytes
+			req.Headers = map[string]string{"Content-Type": "application/json"}
+		}
+		for key := range item.header {
+			if req.Headers == nil {
+				req.Headers = map[string]string{}
+			}
+			req.Headers[key] = item.header.Get(key)
+		}
+		requests = append(requests, req)
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	bodyBytes, err := json.Marshal(struct {
+		Requests []batchRequestItem `json:"requests"`
+	}{Requests: requests})
+	if err != nil {
+		return err
+	}
+	var res struct {
+		Responses []batchResponseItem `json:"responses"`
+	}
+	reqParams := compileCreateQueryOptions([]CreateQueryOption{CreateWithContext(ctx)})
+	err = b.graphClient.makePOSTAPICall("/$batch", reqParams, bytes.NewReader(bodyBytes), &res)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]*BatchItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	for _, response := range res.Responses {
+		if item, ok := byID[response.ID]; ok {
+			item.setResponse(ctx, b.graphClient, response)
+		}
+	}
+	for _, item := range items {
+		if !item.executed { // Hint: should not happen, the ms graph API returns a response for every request
+			item.setFailedDependency()
+		}
+	}
+	return nil
+}
+
+// setResponse processes the response of this request, hence unmarshals the body or sets the error
+func (b *BatchItem) setResponse(ctx context.Context, graphClient *GraphClient, response batchResponseItem) {
+	b.executed = true
+	b.StatusCode = response.Status
+	b.Header = http.Header{}
+	for key, value := range response.Headers {
+		b.Header.Set(key, value)
+	}
+
+	if response.Status < 200 || response.Status > 299 {
+		b.err = newGraphError(&http.Response{StatusCode: response.Status, Header: b.Header}, response.Body)
+		return
+	}
+	if b.v == nil || len(response.Body) == 0 {
+		return
+	}
+	body, err := graphClient.followNextLinks(ctx, b.Method, response.Body, nil)
+	if err != nil {
+		b.err = err
+		return
+	}
+	if err := json.Unmarshal(body, b.v); err != nil {
+		b.err = err
+		return
+	}
+	if b.finish != nil {
+		b.finish()
+	}
+}
+
+// setFailedDependency marks this request as failed because a request it depends on failed
+func (b *BatchItem) setFailedDependency() {
+	b.executed = true
+	b.StatusCode = http.StatusFailedDependency
+	b.err = &GraphError{
+		StatusCode: http.StatusFailedDependency,
+		Code:       "FailedDependency",
+		Message:    "a request this request depends on has failed",
+		Header:     http.Header{},
+	}
+}
@@ -0,0 +1,153 @@
+package msgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/SerenityITS-Development/go-msgraph/msgraphtest"
+)
+
+func TestBatchRequest_Execute(t *testing.T) {
+	// users are found unless their identifier starts with "missing", every sub-request is recorded in requests
+	var batchCalls int32
+	var requests []batchRequestItem
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.0/$batch" || r.Method != http.MethodPost {
+			t.Errorf("unexpected request %v %v", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(&batchCalls, 1)
+		var batch struct {
+			Requests []batchRequestItem `json:"requests"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Fatalf("cannot decode $batch request: %v", err)
+		}
+		if len(batch.Requests) > MaxBatchSize {
+			t.Errorf("$batch request contains %d requests, want at most %d", len(batch.Requests), MaxBatchSize)
+		}
+		var responses []string
+		for _, req := range batch.Requests {
+			requests = append(requests, req)
+			if strings.HasPrefix(req.URL, "/users/missing") {
+				responses = append(responses, fmt.Sprintf(`{"id":"%v","status":404,"headers":{"request-id":"req-%v"},`+
+					`"body":{"error":{"code":"Request_ResourceNotFound","message":"not found"}}}`, req.ID, req.ID))
+				continue
+			}
+			id := strings.SplitN(strings.TrimPrefix(req.URL, "/users/"), "?", 2)[0]
+			responses = append(responses, fmt.Sprintf(`{"id":"%v","status":200,"headers":{"Content-Type":"application/json"},`+
+				`"body":{"id":"%v"}}`, req.ID, id))
+		}
+		fmt.Fprintf(w, `{"responses":[%v]}`, strings.Join(responses, ","))
+	})
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL)
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+
+	t.Run("split into chunks", func(t *testing.T) {
+		atomic.StoreInt32(&batchCalls, 0)
+		batch := g.NewBatch()
+		users := make([]User, 45)
+		for i := range users {
+			batch.GetUser(fmt.Sprint(i), &users[i], GetWithSelect("id"))
+		}
+		if err := batch.Execute(context.Background()); err != nil {
+			t.Fatalf("BatchRequest.Execute() error = %v", err)
+		}
+		if got := atomic.LoadInt32(&batchCalls); got != 3 {
+			t.Errorf("BatchRequest.Execute() made %d $batch API-calls, want 3", got)
+		}
+		for i, user := range users {
+			if user.ID != fmt.Sprint(i) || user.graphClient == nil {
+				t.Errorf("BatchRequest.GetUser() = %v, want ID %d and graphClient set", user, i)
+			}
+		}
+	})
+
+	t.Run("per request errors and dependencies", func(t *testing.T) {
+		requests = nil
+		batch := g.NewBatch()
+		var alice, missing, bob, carol User
+		aliceItem := batch.GetUser("alice", &alice)
+		missingItem := batch.GetUser("missing", &missing)
+		bobItem := batch.GetUser("bob", &bob).DependsOn(aliceItem)
+		for i := 0; i < MaxBatchSize; i++ {
+			batch.GetUser(fmt.Sprint(i), &User{})
+		}
+		carolItem := batch.GetUser("carol", &carol).DependsOn(missingItem) // second chunk
+		if err := batch.Execute(context.Background()); err != nil {
+			t.Fatalf("BatchRequest.Execute() error = %v", err)
+		}
+
+		if aliceItem.Err() != nil || alice.ID != "alice" || bobItem.Err() != nil || bob.ID != "bob" {
+			t.Errorf("BatchItem.Err() = %v, %v, want nil", aliceItem.Err(), bobItem.Err())
+		}
+		var graphErr *GraphError
+		if !errors.As(missingItem.Err(), &graphErr) || !errors.Is(graphErr, ErrNotFound) || graphErr.RequestID() != "req-2" {
+			t.Errorf("BatchItem.Err() = %v, want GraphError 404 with request-id req-2", missingItem.Err())
+		}
+		if carolItem.StatusCode != http.StatusFailedDependency || carolItem.Err() == nil || carol.ID != "" {
+			t.Errorf("BatchItem with failed dependency: StatusCode = %v, Err() = %v", carolItem.StatusCode, carolItem.Err())
+		}
+		if len(requests) != MaxBatchSize+3 {
+			t.Errorf("BatchRequest.Execute() sent %d requests, want %d", len(requests), MaxBatchSize+3)
+		}
+		if fmt.Sprint(requests[2].DependsOn) != "[1]" {
+			t.Errorf("BatchRequest.Execute() dependsOn = %v, want [1]", requests[2].DependsOn)
+		}
+	})
+
+	t.Run("dependency not added before", func(t *testing.T) {
+		batch := g.NewBatch()
+		other := g.NewBatch().GetUser("alice", &User{})
+		batch.GetUser("bob", &User{}).DependsOn(other)
+		if err := batch.Execute(context.Background()); err == nil {
+			t.Errorf("BatchRequest.Execute() error = nil, want error for unknown dependency")
+		}
+	})
+}
+
+func TestBatchRequest_genericRequests(t *testing.T) {
+	srv, g := newTestClient(t)
+	alice := srv.AddUser(msgraphtest.Object{"displayName": "Alice", "userPrincipalName": "alice@contoso.com"})
+	technicians := srv.AddGroup(msgraphtest.Object{"displayName": "technicians"})
+	obsolete := srv.AddGroup(msgraphtest.Object{"displayName": "obsolete"})
+	srv.AddMember(technicians["id"].(string), alice["id"].(string))
+
+	batch := g.NewBatch()
+	var got Group
+	var members Users
+	var created Group
+	items := []*BatchItem{
+		batch.Get("/groups/"+technicians["id"].(string), &got, GetWithSelect("id,displayName")),
+		batch.List("/groups/"+technicians["id"].(string)+"/members", &members, ListWithSelect("id")),
+		batch.Create("/groups", Group{DisplayName: "new"}, &created),
+		batch.Update("/users/"+alice["id"].(string), User{DisplayName: "Alice Smith"}),
+		batch.Delete("/groups/" + obsolete["id"].(string)),
+	}
+	if err := batch.Execute(context.Background()); err != nil {
+		t.Fatalf("BatchRequest.Execute() error = %v", err)
+	}
+	for _, item := range items {
+		if item.Err() != nil {
+			t.Errorf("BatchItem %v %v error = %v", item.Method, item.URL, item.Err())
+		}
+	}
+	if got.DisplayName != "technicians" || len(members) != 1 || members[0].ID != alice["id"] || created.ID == "" {
+		t.Errorf("BatchRequest results = %v, %v, %v, want technicians with alice and the created group", got, members, created)
+	}
+	if obj, _ := srv.Get("users", alice["id"].(string)); obj["displayName"] != "Alice Smith" {
+		t.Errorf("updated user = %v, want displayName Alice Smith", obj)
+	}
+	if _, ok := srv.Get("groups", obsolete["id"].(string)); ok {
+		t.Errorf("deleted group still exists")
+	}
+}
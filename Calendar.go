@@ -1,7 +1,6 @@
 package msgraph
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -42,9 +41,7 @@ func (c *Calendar) ShareReadWith(email EmailAddress, isInsideOrganization bool,
 	}
 
 	resource := fmt.Sprintf("/users/%v/calendars/%v/calendarPermissions", c.Owner.Address, c.ID)
-
-	calendarPermission := CalendarPermission{graphClient: c.graphClient, calendar: c}
-	bodyBytes, err := json.Marshal(struct {
+	calendarPermission, err := NewCollection[CalendarPermission](c.graphClient, resource).Create(struct {
 		IsInsideOrganization bool `json:"isInsideOrganization"`
 		IsRemovable bool `json:"isRemovable"`
 		Role string `json:"role"`
@@ -54,13 +51,8 @@ func (c *Calendar) ShareReadWith(email EmailAddress, isInsideOrganization bool,
 		IsInsideOrganization: isInsideOrganization,
 		Role: role,
 		EmailAppliedTo: email,
-	})
-	if err != nil {
-		return calendarPermission, err
-	}
-
-	reader := bytes.NewReader(bodyBytes)
-	err = c.graphClient.makePOSTAPICall(resource, compileCreateQueryOptions(opts), reader, &calendarPermission)
+	}, opts...)
+	calendarPermission.calendar = c
 	return calendarPermission, err
 }
 
@@ -74,27 +66,14 @@ func (c Calendar) CreateEvent(event CalendarEvent, opts ...CreateQueryOption) (*
 		return nil, err
 	}
 
-	if len(globalSupportedTimeZones.Value) == 0 {
-		var err error
-		// TODO: this is a dirty fix, because opts could contain other things than a context, e.g. select
-		// parameters. This could produce unexpected outputs and therefore break the globalSupportedTimeZones variable.
-		globalSupportedTimeZones, err = user.getTimeZoneChoices(compileCreateQueryOptions(opts))
-		if err != nil {
-			return nil, err
-		}
+	// TODO: this is a dirty fix, because opts could contain other things than a context, e.g. select
+	// parameters. This could produce unexpected outputs and therefore break the globalSupportedTimeZones variable.
+	if err := loadGlobalSupportedTimeZones(user, compileCreateQueryOptions(opts)); err != nil {
+		return nil, err
 	}
 
 	resource := fmt.Sprintf("/users/%v/calendars/%v/events", c.Owner.Address, c.ID)
-	newEvent := CalendarEvent{graphClient: c.graphClient}
-	bodyBytes, err := json.Marshal(event)
-	if err != nil {
-		return &newEvent, err
-	}
-
-
-	reader := bytes.NewReader(bodyBytes)
-	err = c.graphClient.makePOSTAPICall(resource, compileCreateQueryOptions(opts), reader, &newEvent)
-
+	newEvent, err := NewCollection[CalendarEvent](c.graphClient, resource).Create(event, opts...)
 	return &newEvent, err
 }
 
@@ -102,15 +81,8 @@ func (c Calendar) CreateEvent(event CalendarEvent, opts ...CreateQueryOption) (*
 //
 // Reference: https://docs.microsoft.com/en-us/graph/api/user-delete
 func (c Calendar) Delete(opts ...DeleteQueryOption) error {
-	if c.graphClient == nil {
-		return ErrNotGraphClientSourced
-	}
-
-	resource := fmt.Sprintf("/users/%v/calendars/%v", c.Owner.Address, c.ID)
-
 	// TODO: check return body, maybe there is some potential success or error message hidden in it?
-	err := c.graphClient.makeDELETEAPICall(resource, compileDeleteQueryOptions(opts), nil)
-	return err
+	return NewItem[Calendar](c.graphClient, fmt.Sprintf("/users/%v/calendars/%v", c.Owner.Address, c.ID)).Delete(opts...)
 }
 
 func (c Calendar) ListEvents(startDateTime, endDateTime time.Time, opts ...ListQueryOption) (CalendarEvents, error) {
@@ -123,14 +95,10 @@ func (c Calendar) ListEvents(startDateTime, endDateTime time.Time, opts ...ListQ
 		return nil, err
 	}
 
-	if len(globalSupportedTimeZones.Value) == 0 {
-		var err error
-		// TODO: this is a dirty fix, because opts could contain other things than a context, e.g. select
-		// parameters. This could produce unexpected outputs and therefore break the globalSupportedTimeZones variable.
-		globalSupportedTimeZones, err = user.getTimeZoneChoices(compileListQueryOptions(opts))
-		if err != nil {
-			return CalendarEvents{}, err
-		}
+	// TODO: this is a dirty fix, because opts could contain other things than a context, e.g. select
+	// parameters. This could produce unexpected outputs and therefore break the globalSupportedTimeZones variable.
+	if err := loadGlobalSupportedTimeZones(user, compileListQueryOptions(opts)); err != nil {
+		return CalendarEvents{}, err
 	}
 
 	resource := fmt.Sprintf("/users/%v/calendars/%v/events", c.Owner.Address, c.ID)
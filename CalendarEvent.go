@@ -2,7 +2,6 @@ package msgraph
 //@file: goland:noinspection SpellCheckingInspection
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -43,6 +42,7 @@ type CalendarEvent struct {
 	CancelledOccurrences    *[]string `json:"cancelledOccurrences,omitempty"`
 	Categories			    *[]string `json:"categories,omitempty"`
 	ChangeKey				*string `json:"-"`
+	ETag					*string `json:"-"` // the @odata.etag at read time, see UpdateWithConcurrencyCheck
 	ExceptionOccurrences    *[]string `json:"-"`
 	HasAttachments			*bool `json:"hasAttachments,omitempty"`
 	IsDraft					*bool `json:"isDraft,omitempty"`
@@ -61,9 +61,9 @@ type CalendarEvent struct {
 	Recurrence				*PatternedRecurrence `json:"recurrence,omitempty"`
 }
 
-func (c CalendarEvent) setGraphClient(gC *GraphClient) CalendarEvent {
+// setGraphClient sets the graphClient instance in this instance and all child-instances (if any)
+func (c *CalendarEvent) setGraphClient(gC *GraphClient) {
 	c.graphClient = gC
-	return c
 }
 
 // GetFirstAttendee returns the first Attendee that is not the organizer of the event from the Attendees array.
@@ -95,22 +95,38 @@ func (c CalendarEvent) PrettySimpleString() string {
 	return fmt.Sprintf("{ %v (%v) [%v - %v] }", c.Subject, c.GetFirstAttendee().EmailAddress.Name, c.StartTime, c.EndTime)
 }
 
+// Update patches the event with all its properties. Pass UpdateWithConcurrencyCheck to send the ETag
+// captured at read time as If-Match, hence the update fails with ErrPreconditionFailed instead of
+// overwriting concurrent changes, e.g. of an Outlook client. See Mutate to retry in that case.
 func (c *CalendarEvent) Update(opts ... UpdateQueryOption) error {
 	if c.graphClient == nil {
 		return ErrNotGraphClientSourced
 	}
 
-	resource := fmt.Sprintf("/users/%v/events/%v", c.Organizer.EmailAddress.Address, *c.ID)
+	// TODO: check return body, maybe there is some potential success or error message hidden in it?
+	return c.item().Update(c, append(opts, updateWithETag(c.etag()))...)
+}
 
-	bodyBytes, err := json.Marshal(c)
+// Mutate re-reads the event, applies the given mutate func to it and updates it if it has not been changed
+// in the meantime, otherwise it retries, see Item.Mutate. The event is set to the updated one afterwards.
+func (c *CalendarEvent) Mutate(mutate func(event *CalendarEvent) error, opts ...UpdateQueryOption) error {
+	if c.graphClient == nil {
+		return ErrNotGraphClientSourced
+	}
+	updated, err := c.item().Mutate(mutate, opts...)
 	if err != nil {
 		return err
 	}
-	reader := bytes.NewReader(bodyBytes)
+	*c = updated
+	return nil
+}
 
-	// TODO: check return body, maybe there is some potential success or error message hidden in it?
-	err = c.graphClient.makePATCHAPICall(resource, compileUpdateQueryOptions(opts), reader, nil)
-	return err
+// etag returns the @odata.etag captured at read time, empty if there is none
+func (c CalendarEvent) etag() string {
+	if c.ETag == nil {
+		return ""
+	}
+	return *c.ETag
 }
 
 func (c CalendarEvent) Delete(opts ...DeleteQueryOption) error {
@@ -118,11 +134,13 @@ func (c CalendarEvent) Delete(opts ...DeleteQueryOption) error {
 		return ErrNotGraphClientSourced
 	}
 
-	resource := fmt.Sprintf("/users/%v/events/%v", c.Organizer.EmailAddress.Address, *c.ID)
-
 	// TODO: check return body, maybe there is some potential success or error message hidden in it?
-	err := c.graphClient.makeDELETEAPICall(resource, compileDeleteQueryOptions(opts), nil)
-	return err
+	return c.item().Delete(opts...)
+}
+
+// item returns the Item of this event in the calendar of its organizer for the generic API-calls
+func (c CalendarEvent) item() Item[CalendarEvent] {
+	return NewItem[CalendarEvent](c.graphClient, fmt.Sprintf("/users/%v/events/%v", c.Organizer.EmailAddress.Address, *c.ID))
 }
 
 // Equal returns wether the CalendarEvent is identical to the given CalendarEvent
@@ -166,6 +184,7 @@ func (c *CalendarEvent) UnmarshalJSON(data []byte) error {
 		CancelledOccurrences    *[]string		`json:"cancelledOccurrences,omitempty"`
 		Categories			    *[]string		`json:"categories,omitempty"`
 		ChangeKey				*string			`json:"changeKey,omitempty"`
+		ETag					*string			`json:"@odata.etag,omitempty"`
 		ExceptionOccurrences    *[]string		`json:"exceptionOccurrences,omitempty"`
 		HasAttachments			*bool			`json:"hasAttachments,omitempty"`
 		IsDraft					*bool			`json:"isDraft,omitempty"`
@@ -248,6 +267,7 @@ func (c *CalendarEvent) UnmarshalJSON(data []byte) error {
 	c.CancelledOccurrences = tmp.CancelledOccurrences
 	c.Categories = tmp.Categories
 	c.ChangeKey = tmp.ChangeKey
+	c.ETag = tmp.ETag
 	c.ExceptionOccurrences = tmp.ExceptionOccurrences
 	c.HasAttachments = tmp.HasAttachments
 	c.IsDraft = tmp.IsDraft
@@ -333,7 +353,7 @@ func mapTimeZoneStrings(timeZone string) (*time.Location, error) {
 	if timeZone == "tzone://Microsoft/Custom" {
 		return FullDayEventTimeZone, nil
 	}
-	tz, err := globalSupportedTimeZones.GetTimeZoneByAlias(timeZone)
+	tz, err := getGlobalSupportedTimeZones().GetTimeZoneByAlias(timeZone)
 	if err == nil {
 		return tz, nil
 	}
@@ -0,0 +1,59 @@
+package msgraph
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SerenityITS-Development/go-msgraph/msgraphtest"
+)
+
+func TestCalendarEvent_Update(t *testing.T) {
+	srv, g := newTestClient(t)
+	alice := srv.AddUser(msgraphtest.Object{"displayName": "Alice", "userPrincipalName": "alice@contoso.test", "mail": "alice@contoso.test"})
+	start := time.Now().UTC().Add(time.Hour).Truncate(time.Hour)
+	srv.AddEvent(alice["id"].(string), "", msgraphtest.Object{"subject": "Team meeting",
+		"start": map[string]string{"dateTime": start.Format("2006-01-02T15:04:05.0000000"), "timeZone": "UTC"},
+		"end":   map[string]string{"dateTime": start.Add(time.Hour).Format("2006-01-02T15:04:05.0000000"), "timeZone": "UTC"}})
+	user, err := g.GetUser(alice["id"].(string))
+	if err != nil {
+		t.Fatalf("GraphClient.GetUser() error = %v", err)
+	}
+	listEvent := func() CalendarEvent {
+		t.Helper()
+		events, err := user.ListCalendarView(start.Add(-time.Hour), start.Add(2*time.Hour))
+		if err != nil || len(events) != 1 || events[0].ETag == nil || events[0].ChangeKey == nil {
+			t.Fatalf("User.ListCalendarView() = %v, %v, want one event with ETag and ChangeKey", events, err)
+		}
+		return events[0]
+	}
+	first, second := listEvent(), listEvent()
+
+	subject := "Team meeting (moved)"
+	first.Subject = &subject
+	if err := first.Update(UpdateWithConcurrencyCheck()); err != nil {
+		t.Errorf("CalendarEvent.Update() error = %v", err)
+	}
+	if err := second.Update(UpdateWithConcurrencyCheck()); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("CalendarEvent.Update() of a changed event error = %v, want ErrPreconditionFailed", err)
+	}
+	// the ChangeKey is no etag, without @odata.etag there is nothing to send as If-Match
+	second.ETag = nil
+	if err := second.Update(UpdateWithConcurrencyCheck()); !errors.Is(err, ErrNoETag) {
+		t.Errorf("CalendarEvent.Update() without ETag error = %v, want ErrNoETag", err)
+	}
+
+	if err := second.Mutate(func(event *CalendarEvent) error {
+		moved := *event.Subject + " again"
+		event.Subject = &moved
+		return nil
+	}); err != nil {
+		t.Fatalf("CalendarEvent.Mutate() error = %v", err)
+	}
+	if *second.Subject != "Team meeting (moved) again" {
+		t.Errorf("CalendarEvent.Mutate() = %v, want the subject Team meeting (moved) again", *second.Subject)
+	}
+	if got := listEvent(); *got.Subject != "Team meeting (moved) again" {
+		t.Errorf("mutated event subject = %v, want Team meeting (moved) again", *got.Subject)
+	}
+}
@@ -1,7 +1,6 @@
 package msgraph
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 )
@@ -30,27 +29,13 @@ func (cG *CalendarGroup) setGraphClient(graphClient *GraphClient) {
 // Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user-post-calendars
 func (cG *CalendarGroup) CreateCalendar(name string, opts ...CreateQueryOption) (Calendar, error) {
 
-	if cG.graphClient == nil {
+	if cG.graphClient == nil || cG.user == nil {
 		return Calendar{}, ErrNotGraphClientSourced
 	}
 
-	resource := fmt.Sprintf("/users/%v/calendarGroups/%v/calendars", cG.user.ID, cG.ID)
-	calendar := Calendar{graphClient: cG.graphClient}
-	bodyBytes, err := json.Marshal(struct {
+	return cG.calendars().Create(struct {
 		Name string `json:"name"`
-	}{Name: name})
-	if err != nil {
-		return Calendar{}, err
-	}
-	if err != nil {
-		return calendar, err
-	}
-
-	reader := bytes.NewReader(bodyBytes)
-	err = cG.graphClient.makePOSTAPICall(resource, compileCreateQueryOptions(opts), reader, &calendar)
-
-	calendar.setGraphClient(cG.graphClient)
-	return calendar, err
+	}{Name: name}, opts...)
 }
 
 // ListCalendars returns all calendars associated to that user and group.
@@ -58,33 +43,29 @@ func (cG *CalendarGroup) CreateCalendar(name string, opts ...CreateQueryOption)
 //
 // Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user-list-calendars
 func (cG CalendarGroup) ListCalendars(opts ...ListQueryOption) (Calendars, error) {
-	if cG.graphClient == nil {
+	if cG.graphClient == nil || cG.user == nil {
 		return Calendars{}, ErrNotGraphClientSourced
 	}
-	resource := fmt.Sprintf("/users/%v/calendarGroups/%v/calendars", cG.user.ID, cG.ID)
-
-	var marsh struct {
-		Calendars Calendars `json:"value"`
-	}
-	err := cG.graphClient.makeGETAPICall(resource, compileListQueryOptions(opts), &marsh)
-	marsh.Calendars.setGraphClient(cG.graphClient)
-	return marsh.Calendars, err
+	return cG.calendars().List(opts...)
 }
 
 // Delete deletes this user instance at the Microsoft Azure AD. Use with caution.
 //
 // Reference: https://docs.microsoft.com/en-us/graph/api/user-delete
 func (cG CalendarGroup) Delete(opts ...DeleteQueryOption) error {
-	if cG.graphClient == nil {
+	if cG.graphClient == nil || cG.user == nil {
 		return ErrNotGraphClientSourced
 	}
 
-	resource := fmt.Sprintf("/users/%v/calendarGroups/%v", cG.user.ID, cG.ID)
-
 	// TODO: check return body, maybe there is some potential success or error message hidden in it?
 	// TODO: delete any child calendars
-	err := cG.graphClient.makeDELETEAPICall(resource, compileDeleteQueryOptions(opts), nil)
-	return err
+	return NewItem[CalendarGroup](cG.graphClient, fmt.Sprintf("/users/%v/calendarGroups/%v", cG.user.ID, cG.ID)).Delete(opts...)
+}
+
+// calendars returns the Collection of the calendars of this calendar group. The calendar group must have been
+// loaded by a User, hence cG.user is set: the generic layer only sets the GraphClient.
+func (cG CalendarGroup) calendars() Collection[Calendar] {
+	return NewCollection[Calendar](cG.graphClient, fmt.Sprintf("/users/%v/calendarGroups/%v/calendars", cG.user.ID, cG.ID))
 }
 
 
@@ -7,10 +7,12 @@ import (
 
 func TestCalendarGroup(t *testing.T) {
 	t.Run("Create, List, GetByName, Delete CalendarGroup", func(t *testing.T) {
-		client, err := NewGraphClient(
+		client, err := NewGraphClientWithCustomEndpoint(
 			msGraphTenantID,
 			msGraphApplicationID,
-			msGraphClientSecret)
+			msGraphClientSecret,
+			msGraphAzureADAuthEndpoint,
+			msGraphServiceRootEndpoint)
 		if err != nil {
 			log.Fatalf("failed to create graph client: %v", err)
 		}
@@ -48,10 +50,12 @@ func TestCalendarGroup(t *testing.T) {
 	})
 
 	t.Run("Get Calendar from My Calendars Group", func(t *testing.T) {
-		client, err := NewGraphClient(
+		client, err := NewGraphClientWithCustomEndpoint(
 			msGraphTenantID,
 			msGraphApplicationID,
-			msGraphClientSecret)
+			msGraphClientSecret,
+			msGraphAzureADAuthEndpoint,
+			msGraphServiceRootEndpoint)
 		if err != nil {
 			log.Fatalf("failed to create graph client: %v", err)
 		}
@@ -23,6 +23,11 @@ func (cP CalendarPermission) String() string {
 		cP.AllowedRoles, cP.EmailAppliedTo)
 }
 
+// setGraphClient sets the graphClient instance in this instance and all child-instances (if any)
+func (cP *CalendarPermission) setGraphClient(gC *GraphClient) {
+	cP.graphClient = gC
+}
+
 func (cP *CalendarPermission) Delete(opts ...DeleteQueryOption) error {
 	if cP.graphClient == nil {
 		return ErrNotGraphClientSourced
@@ -33,8 +38,7 @@ func (cP *CalendarPermission) Delete(opts ...DeleteQueryOption) error {
 
 	// TODO: check return body, maybe there is some potential success or error message hidden in it?
 	// TODO: delete any child calendars
-	err := cP.graphClient.makeDELETEAPICall(resource, compileDeleteQueryOptions(opts), nil)
-	return err
+	return NewItem[CalendarPermission](cP.graphClient, resource).Delete(opts...)
 }
 
 
@@ -33,10 +33,12 @@ func TestCalendar_String(t *testing.T) {
 }
 
 func TestCalendar_ShareReadWith(t *testing.T) {
-	client, err := NewGraphClient(
+	client, err := NewGraphClientWithCustomEndpoint(
 		msGraphTenantID,
 		msGraphApplicationID,
-		msGraphClientSecret)
+		msGraphClientSecret,
+		msGraphAzureADAuthEndpoint,
+		msGraphServiceRootEndpoint)
 	if err != nil {
 		log.Fatalf("failed to create graph client: %v", err)
 	}
@@ -101,10 +103,12 @@ func TestCalendar_CreateEvent(t *testing.T) {
 		TransactionID:         &transactionId,
 	}
 
-	client, err := NewGraphClient(
+	client, err := NewGraphClientWithCustomEndpoint(
 		msGraphTenantID,
 		msGraphApplicationID,
-		msGraphClientSecret)
+		msGraphClientSecret,
+		msGraphAzureADAuthEndpoint,
+		msGraphServiceRootEndpoint)
 	if err != nil {
 		log.Fatalf("failed to create graph client: %v", err)
 	}
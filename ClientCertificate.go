@@ -0,0 +1,135 @@
+package msgraph
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// clientAssertionType is the client_assertion_type of a certificate based client assertion
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// clientAssertionLifetime is the validity of a client assertion JWT, it is only used once to get a Token
+const clientAssertionLifetime = 10 * time.Minute
+
+// ClientCertificate is a X.509 certificate and its RSA private key used to authenticate the application
+// instead of a ClientSecret. The certificate must be uploaded to the application registration in Azure AD.
+// Create it with NewClientCertificateFromPEM or NewClientCertificateFromPKCS12.
+//
+// See https://docs.microsoft.com/en-us/azure/active-directory/develop/active-directory-certificate-credentials
+type ClientCertificate struct {
+	Certificate *x509.Certificate
+	PrivateKey  *rsa.PrivateKey
+}
+
+// NewClientCertificateFromPEM parses a PEM encoded certificate and its unencrypted private key, e.g. the content
+// of a .pem file containing both or the concatenated content of a .crt and a .key file. The private key may be
+// in PKCS #1 ("RSA PRIVATE KEY") or PKCS #8 ("PRIVATE KEY") format.
+func NewClientCertificateFromPEM(pemData []byte) (ClientCertificate, error) {
+	var certificate *x509.Certificate
+	var privateKey interface{}
+	for block, rest := pem.Decode(pemData); block != nil; block, rest = pem.Decode(rest) {
+		var err error
+		switch block.Type {
+		case "CERTIFICATE":
+			if certificate == nil { // the first certificate is the leaf, further ones are the chain
+				certificate, err = x509.ParseCertificate(block.Bytes)
+			}
+		case "RSA PRIVATE KEY":
+			privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "PRIVATE KEY":
+			privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+		if err != nil {
+			return ClientCertificate{}, fmt.Errorf("cannot parse PEM block %v: %w", block.Type, err)
+		}
+	}
+	if certificate == nil {
+		return ClientCertificate{}, fmt.Errorf("no CERTIFICATE found in PEM data")
+	}
+	if privateKey == nil {
+		return ClientCertificate{}, fmt.Errorf("no unencrypted PRIVATE KEY found in PEM data")
+	}
+	return newClientCertificate(certificate, privateKey)
+}
+
+// NewClientCertificateFromPKCS12 parses a PKCS #12 archive, e.g. the content of a .pfx or .p12 file, containing
+// the certificate and its private key, protected by the given password.
+func NewClientCertificateFromPKCS12(pfxData []byte, password string) (ClientCertificate, error) {
+	privateKey, certificate, _, err := pkcs12.DecodeChain(pfxData, password)
+	if err != nil {
+		return ClientCertificate{}, fmt.Errorf("cannot decode PKCS #12 data: %w", err)
+	}
+	return newClientCertificate(certificate, privateKey)
+}
+
+// newClientCertificate checks that the privateKey is a RSA key that belongs to the certificate
+func newClientCertificate(certificate *x509.Certificate, privateKey interface{}) (ClientCertificate, error) {
+	rsaKey, ok := privateKey.(*rsa.PrivateKey)
+	if !ok {
+		return ClientCertificate{}, fmt.Errorf("private key of type %T is not supported, must be RSA", privateKey)
+	}
+	if !rsaKey.PublicKey.Equal(certificate.PublicKey) {
+		return ClientCertificate{}, fmt.Errorf("private key does not match the certificate %v", certificate.Subject)
+	}
+	return ClientCertificate{Certificate: certificate, PrivateKey: rsaKey}, nil
+}
+
+// Thumbprint returns the base64url encoded SHA-1 thumbprint of the certificate, as sent in the x5t header
+// of the client assertion
+func (c ClientCertificate) Thumbprint() string {
+	thumbprint := sha1.Sum(c.Certificate.Raw)
+	return base64.RawURLEncoding.EncodeToString(thumbprint[:])
+}
+
+// clientAssertion returns a JWT signed with the private key to authenticate the application with the given
+// clientID at the token endpoint given as audience
+func (c ClientCertificate) clientAssertion(audience, clientID string) (string, error) {
+	if c.Certificate == nil || c.PrivateKey == nil {
+		return "", fmt.Errorf("client certificate or private key is missing")
+	}
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	now := time.Now()
+
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"x5t": c.Thumbprint(),
+	})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": audience,
+		"iss": clientID,
+		"sub": clientID,
+		"jti": fmt.Sprintf("%x-%x-%x-%x-%x", jti[0:4], jti[4:6], jti[6:8], jti[8:10], jti[10:]),
+		"nbf": now.Unix(),
+		"iat": now.Unix(),
+		"exp": now.Add(clientAssertionLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, c.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("cannot sign client assertion: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
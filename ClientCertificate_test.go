@@ -0,0 +1,127 @@
+package msgraph
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// newTestCertificate creates a self-signed certificate and its RSA private key
+func newTestCertificate(t *testing.T) (*x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "go-msgraph test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() error = %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate() error = %v", err)
+	}
+	return certificate, key
+}
+
+func TestNewClientCertificate(t *testing.T) {
+	certificate, key := newTestCertificate(t)
+	_, otherKey := newTestCertificate(t)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
+	pkcs1PEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+	pkcs8PEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})
+	otherPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(otherKey)})
+	pfx, err := pkcs12.Modern.Encode(key, certificate, nil, "password")
+	if err != nil {
+		t.Fatalf("pkcs12.Encode() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		parse   func() (ClientCertificate, error)
+		wantErr bool
+	}{
+		{name: "PEM with PKCS #1 key", parse: func() (ClientCertificate, error) { return NewClientCertificateFromPEM(append(certPEM, pkcs1PEM...)) }},
+		{name: "PEM with PKCS #8 key first", parse: func() (ClientCertificate, error) { return NewClientCertificateFromPEM(append(pkcs8PEM, certPEM...)) }},
+		{name: "PEM without key", parse: func() (ClientCertificate, error) { return NewClientCertificateFromPEM(certPEM) }, wantErr: true},
+		{name: "PEM with other key", parse: func() (ClientCertificate, error) { return NewClientCertificateFromPEM(append(certPEM, otherPEM...)) }, wantErr: true},
+		{name: "PKCS #12", parse: func() (ClientCertificate, error) { return NewClientCertificateFromPKCS12(pfx, "password") }},
+		{name: "PKCS #12 wrong password", parse: func() (ClientCertificate, error) { return NewClientCertificateFromPKCS12(pfx, "wrong") }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parse()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parse error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (!got.Certificate.Equal(certificate) || !got.PrivateKey.Equal(key)) {
+				t.Errorf("parse returned another certificate or key")
+			}
+		})
+	}
+}
+
+func TestNewGraphClientWithCertificate(t *testing.T) {
+	certificate, key := newTestCertificate(t)
+	var srvURL string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("ParseForm() error = %v", err)
+		}
+		if r.PostForm.Get("client_secret") != "" || r.PostForm.Get("client_assertion_type") != clientAssertionType {
+			t.Errorf("token request %v, want client_assertion instead of client_secret", r.PostForm)
+		}
+		parts := strings.Split(r.PostForm.Get("client_assertion"), ".")
+		if len(parts) != 3 {
+			t.Fatalf("client_assertion %v is not a JWT", r.PostForm.Get("client_assertion"))
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("client_assertion signature is invalid: %v", err)
+		}
+		var header, claims map[string]interface{}
+		headerBytes, _ := base64.RawURLEncoding.DecodeString(parts[0])
+		claimsBytes, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		json.Unmarshal(headerBytes, &header)
+		json.Unmarshal(claimsBytes, &claims)
+		thumbprint := ClientCertificate{Certificate: certificate}.Thumbprint()
+		if header["alg"] != "RS256" || header["x5t"] != thumbprint {
+			t.Errorf("client_assertion header = %v, want RS256 with x5t %v", header, thumbprint)
+		}
+		if claims["aud"] != srvURL+"/tenant/oauth2/v2.0/token" || claims["iss"] != "app" || claims["sub"] != "app" || claims["jti"] == "" {
+			t.Errorf("client_assertion claims = %v", claims)
+		}
+		writeTestToken(w, "test-token", "")
+	}, nil)
+	srvURL = srv.URL
+
+	g, err := NewGraphClientWithCertificate("tenant", "app", ClientCertificate{Certificate: certificate, PrivateKey: key},
+		ClientWithEndpoints(srv.URL, srv.URL))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCertificate() error = %v", err)
+	}
+	if g.token.AccessToken != "test-token" {
+		t.Errorf("NewGraphClientWithCertificate() token = %v, want test-token", g.token.AccessToken)
+	}
+}
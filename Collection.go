@@ -0,0 +1,266 @@
+package msgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// graphClientSetter is implemented by the resources that keep the GraphClient that loaded them to perform
+// further API-calls, e.g. *User. Collection and Item set the GraphClient of all resources they return.
+type graphClientSetter interface {
+	setGraphClient(gC *GraphClient)
+}
+
+// setGraphClientOf sets the GraphClient of the given resource if it is a graphClientSetter
+func setGraphClientOf[T any](gC *GraphClient, resource *T) {
+	if setter, ok := any(resource).(graphClientSetter); ok {
+		setter.setGraphClient(gC)
+	}
+}
+
+// Collection provides the API-calls of a collection of resources of type T, e.g. the users at /users or the
+// calendars of a user at /users/{id}/calendars. T is the json-unmarshal target of a single resource. Resources
+// of this package, e.g. User, are returned GraphClient sourced, hence their methods can be used right away.
+//
+// Use it for resources that are not implemented by this package yet:
+//
+//	type Application struct {
+//		ID          string `json:"id,omitempty"`
+//		DisplayName string `json:"displayName,omitempty"`
+//	}
+//	applications := msgraph.NewCollection[Application](graphClient, "/applications")
+//	apps, err := applications.List(msgraph.ListWithFilter("startswith(displayName,'contoso')"))
+//	err = applications.Item(apps[0].ID).Update(Application{DisplayName: "Contoso Portal"})
+type Collection[T any] struct {
+	graphClient *GraphClient
+	path        string
+}
+
+// NewCollection returns the Collection of resources of type T at the given path, without API version and
+// with leading slash, e.g. "/users" or "/users/alice@contoso.com/calendars"
+func NewCollection[T any](graphClient *GraphClient, path string) Collection[T] {
+	return Collection[T]{graphClient: graphClient, path: path}
+}
+
+// Path returns the path of the Collection, e.g. "/users"
+func (c Collection[T]) Path() string {
+	return c.path
+}
+
+// Item returns the resource with the given ID of the Collection. No API-call is performed.
+func (c Collection[T]) Item(id string) Item[T] {
+	return NewItem[T](c.graphClient, c.path+"/"+id)
+}
+
+// Get returns the resource with the given ID of the Collection, see Item.Get
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+func (c Collection[T]) Get(id string, opts ...GetQueryOption) (T, error) {
+	return c.Item(id).Get(opts...)
+}
+
+// List returns all resources of the Collection, following the @odata.nextLink of all pages. Use Iterate
+// to load huge collections page by page instead.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+func (c Collection[T]) List(opts ...ListQueryOption) ([]T, error) {
+	if c.graphClient == nil {
+		return nil, ErrNotGraphClientSourced
+	}
+	var marsh struct {
+		Value []T `json:"value"`
+	}
+	err := c.graphClient.makeGETAPICall(c.path, compileListQueryOptions(opts), &marsh)
+	for i := range marsh.Value {
+		setGraphClientOf(c.graphClient, &marsh.Value[i])
+	}
+	return marsh.Value, err
+}
+
+// Iterate returns an Iterator to load the resources of the Collection page by page. Use ListWithPageSize to
+// set the page size and ListWithNextLink to resume.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+func (c Collection[T]) Iterate(opts ...ListQueryOption) Iterator[T] {
+	return Iterator[T]{newPageIterator(c.graphClient, c.path, compileListQueryOptions(opts))}
+}
+
+// Create creates a new resource in the Collection and returns it. Parameter input is json-marshalled as
+// request body, e.g. a T with only the properties to be set or an anonymous struct.
+func (c Collection[T]) Create(input interface{}, opts ...CreateQueryOption) (T, error) {
+	var created T
+	if c.graphClient == nil {
+		return created, ErrNotGraphClientSourced
+	}
+	bodyBytes, err := json.Marshal(input)
+	if err != nil {
+		return created, err
+	}
+
+	reader := bytes.NewReader(bodyBytes)
+	err = c.graphClient.makePOSTAPICall(c.path, compileCreateQueryOptions(opts), reader, &created)
+	setGraphClientOf(c.graphClient, &created)
+	return created, err
+}
+
+// Item provides the API-calls of a single resource of type T, e.g. the user at /users/{id}, see Collection
+type Item[T any] struct {
+	graphClient *GraphClient
+	path        string
+}
+
+// NewItem returns the resource of type T at the given path, without API version and with leading slash,
+// e.g. "/users/alice@contoso.com"
+func NewItem[T any](graphClient *GraphClient, path string) Item[T] {
+	return Item[T]{graphClient: graphClient, path: path}
+}
+
+// Path returns the path of the Item, e.g. "/users/alice@contoso.com"
+func (i Item[T]) Path() string {
+	return i.path
+}
+
+// Get returns the resource
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+func (i Item[T]) Get(opts ...GetQueryOption) (T, error) {
+	var resource T
+	if i.graphClient == nil {
+		return resource, ErrNotGraphClientSourced
+	}
+	err := i.graphClient.makeGETAPICall(i.path, compileGetQueryOptions(opts), &resource)
+	setGraphClientOf(i.graphClient, &resource)
+	return resource, err
+}
+
+// Update patches the resource. Parameter patch is json-marshalled as request body, hence it should
+// only contain the properties to be changed, e.g. a T with omitempty-fields or an anonymous struct.
+// Pass UpdateWithIfMatch to only update the resource if it has not been changed since it was read.
+func (i Item[T]) Update(patch interface{}, opts ...UpdateQueryOption) error {
+	if i.graphClient == nil {
+		return ErrNotGraphClientSourced
+	}
+	reqParams := compileUpdateQueryOptions(opts)
+	if reqParams.concurrencyCheck && reqParams.Headers().Get("If-Match") == "" {
+		return ErrNoETag
+	}
+	bodyBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	reader := bytes.NewReader(bodyBytes)
+	// Hint: API-call body does not return any data / no json object.
+	return i.graphClient.makePATCHAPICall(i.path, reqParams, reader, nil)
+}
+
+// Mutate reads the resource, applies the given mutate func to it and updates it with the @odata.etag read as
+// If-Match header, hence concurrent changes are never overwritten. If the resource has been changed in between,
+// it is read and mutated again, at most MaxConflictRetries times, afterwards the ErrPreconditionFailed is returned.
+// The mutate func should be free of side effects because it may be called several times, an error returned by it
+// is returned as-is without updating the resource.
+//
+// Only the properties changed by the mutate func are sent, hence read-only properties like changeKey are not,
+// properties it cleared are sent as null. The resource is not updated at all if nothing has been changed. The
+// mutated resource is returned, its @odata.etag is the one read before the update, hence read it again before
+// any further update with UpdateWithConcurrencyCheck.
+func (i Item[T]) Mutate(mutate func(resource *T) error, opts ...UpdateQueryOption) (T, error) {
+	var resource T
+	if i.graphClient == nil {
+		return resource, ErrNotGraphClientSourced
+	}
+	reqParams := compileUpdateQueryOptions(opts)
+	getOpts := []GetQueryOption{GetWithContext(reqParams.Context())}
+	if reqParams.apiVersion != "" {
+		getOpts = append(getOpts, GetWithAPIVersion(reqParams.apiVersion))
+	}
+
+	var err error
+	for attempt := 0; attempt <= MaxConflictRetries; attempt++ {
+		var body json.RawMessage
+		if err = i.graphClient.makeGETAPICall(i.path, compileGetQueryOptions(getOpts), &body); err != nil {
+			return resource, err
+		}
+		var version struct {
+			ETag string `json:"@odata.etag"`
+		}
+		if err = json.Unmarshal(body, &version); err != nil {
+			return resource, err
+		}
+		if version.ETag == "" {
+			return resource, ErrNoETag
+		}
+		var zero T
+		resource = zero
+		if err = json.Unmarshal(body, &resource); err != nil {
+			return resource, err
+		}
+		setGraphClientOf(i.graphClient, &resource)
+
+		var before, after []byte
+		if before, err = json.Marshal(&resource); err != nil {
+			return resource, err
+		}
+		if err = mutate(&resource); err != nil {
+			return resource, err
+		}
+		if after, err = json.Marshal(&resource); err != nil {
+			return resource, err
+		}
+		var patch map[string]json.RawMessage
+		if patch, err = changedProperties(before, after); err != nil || len(patch) == 0 {
+			return resource, err
+		}
+		err = i.Update(patch, append(opts, UpdateWithIfMatch(version.ETag))...)
+		if !errors.Is(err, ErrPreconditionFailed) {
+			return resource, err
+		}
+	}
+	return resource, err
+}
+
+// changedProperties returns the properties of the JSON object after that differ from the JSON object before,
+// properties missing in after are returned as null
+func changedProperties(before, after []byte) (map[string]json.RawMessage, error) {
+	var beforeProperties, afterProperties map[string]json.RawMessage
+	if err := json.Unmarshal(before, &beforeProperties); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(after, &afterProperties); err != nil {
+		return nil, err
+	}
+	changed := make(map[string]json.RawMessage)
+	for key, value := range afterProperties {
+		if !bytes.Equal(beforeProperties[key], value) {
+			changed[key] = value
+		}
+	}
+	for key := range beforeProperties {
+		if _, ok := afterProperties[key]; !ok {
+			changed[key] = json.RawMessage("null")
+		}
+	}
+	return changed, nil
+}
+
+// Delete deletes the resource. Use with caution.
+func (i Item[T]) Delete(opts ...DeleteQueryOption) error {
+	if i.graphClient == nil {
+		return ErrNotGraphClientSourced
+	}
+	return i.graphClient.makeDELETEAPICall(i.path, compileDeleteQueryOptions(opts), nil)
+}
+
+// Iterator loads the resources of a Collection page by page, see Collection.Iterate
+type Iterator[T any] struct {
+	pageIterator
+}
+
+// Next loads and returns the next page of resources. Returns ErrNoMorePages if HasNext is false.
+func (it *Iterator[T]) Next() ([]T, error) {
+	var marsh struct {
+		Value []T `json:"value"`
+	}
+	err := it.nextPage(&marsh)
+	for i := range marsh.Value {
+		setGraphClientOf(it.graphClient, &marsh.Value[i])
+	}
+	return marsh.Value, err
+}
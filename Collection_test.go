@@ -0,0 +1,197 @@
+package msgraph
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/SerenityITS-Development/go-msgraph/msgraphtest"
+)
+
+type testApplication struct {
+	ID          string `json:"id,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+type testEvent struct {
+	ID        string `json:"id,omitempty"`
+	ETag      string `json:"@odata.etag,omitempty"`
+	ChangeKey string `json:"changeKey,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+	Location  string `json:"location,omitempty"`
+}
+
+func TestCollection(t *testing.T) {
+	srv, g := newTestClient(t)
+	applications := NewCollection[testApplication](g, "/applications")
+
+	created, err := applications.Create(testApplication{DisplayName: "Portal"})
+	if err != nil || created.ID == "" || created.DisplayName != "Portal" {
+		t.Fatalf("Collection.Create() = %v, %v, want Portal with ID", created, err)
+	}
+	for i := 0; i < 4; i++ {
+		srv.Add("applications", msgraphtest.Object{"displayName": fmt.Sprintf("App %d", i)})
+	}
+
+	got, err := applications.Get(created.ID)
+	if err != nil || got != created {
+		t.Errorf("Collection.Get() = %v, %v, want %v", got, err, created)
+	}
+	if err := applications.Item(created.ID).Update(testApplication{DisplayName: "Contoso Portal"}); err != nil {
+		t.Errorf("Item.Update() error = %v", err)
+	}
+	if obj, _ := srv.Get("applications", created.ID); obj["displayName"] != "Contoso Portal" {
+		t.Errorf("updated application = %v, want displayName Contoso Portal", obj)
+	}
+
+	list, err := applications.List(ListWithPageSize(2))
+	if err != nil || len(list) != 5 {
+		t.Errorf("Collection.List() = %v, %v, want 5 applications", list, err)
+	}
+	it := applications.Iterate(ListWithPageSize(2))
+	var pages, total int
+	for it.HasNext() {
+		page, err := it.Next()
+		if err != nil {
+			t.Fatalf("Iterator.Next() error = %v", err)
+		}
+		pages++
+		total += len(page)
+	}
+	if pages != 3 || total != 5 {
+		t.Errorf("Iterator loaded %d applications in %d pages, want 5 in 3", total, pages)
+	}
+
+	if err := applications.Item(created.ID).Delete(); err != nil {
+		t.Errorf("Item.Delete() error = %v", err)
+	}
+	if _, err := applications.Get(created.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Collection.Get() of a deleted application error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCollection_graphClientSourced(t *testing.T) {
+	srv, g := newTestClient(t)
+	srv.AddUser(msgraphtest.Object{"displayName": "Alice", "userPrincipalName": "alice@contoso.test"})
+	users := NewCollection[User](g, "/users")
+
+	list, err := users.List()
+	if err != nil || len(list) != 1 || list[0].graphClient != g {
+		t.Fatalf("Collection.List() = %v, %v, want Alice with GraphClient", list, err)
+	}
+	user, err := users.Get("alice@contoso.test")
+	if err != nil || user.graphClient != g {
+		t.Errorf("Collection.Get() = %v, %v, want Alice with GraphClient", user, err)
+	}
+	it := users.Iterate()
+	page, err := it.Next()
+	if err != nil || len(page) != 1 || page[0].graphClient != g {
+		t.Errorf("Iterator.Next() = %v, %v, want Alice with GraphClient", page, err)
+	}
+
+	var unsourced Collection[User]
+	if _, err := unsourced.List(); !errors.Is(err, ErrNotGraphClientSourced) {
+		t.Errorf("Collection.List() without GraphClient error = %v, want ErrNotGraphClientSourced", err)
+	}
+	if err := unsourced.Item("alice@contoso.test").Delete(); !errors.Is(err, ErrNotGraphClientSourced) {
+		t.Errorf("Item.Delete() without GraphClient error = %v, want ErrNotGraphClientSourced", err)
+	}
+}
+
+func TestItem_Mutate(t *testing.T) {
+	srv, g := newTestClient(t)
+	applications := NewCollection[testApplication](g, "/applications")
+	created := srv.Add("applications", msgraphtest.Object{"displayName": "Portal"})
+	item := applications.Item(created["id"].(string))
+
+	// a concurrent change between reading and updating is not overwritten, the mutation is retried
+	var calls int
+	updated, err := item.Mutate(func(app *testApplication) error {
+		calls++
+		if calls == 1 {
+			if err := item.Update(testApplication{DisplayName: "Concurrent Portal"}); err != nil {
+				t.Fatalf("Item.Update() error = %v", err)
+			}
+		}
+		app.DisplayName += " v2"
+		return nil
+	})
+	if err != nil || calls != 2 || updated.DisplayName != "Concurrent Portal v2" {
+		t.Errorf("Item.Mutate() = %v, %v after %d calls, want Concurrent Portal v2 after 2 calls", updated, err, calls)
+	}
+	if obj, _ := srv.Get("applications", created["id"].(string)); obj["displayName"] != "Concurrent Portal v2" {
+		t.Errorf("mutated application = %v, want displayName Concurrent Portal v2", obj)
+	}
+
+	// an error of the mutate func is returned as-is
+	errAbort := errors.New("abort")
+	if _, err := item.Mutate(func(app *testApplication) error { return errAbort }); err != errAbort {
+		t.Errorf("Item.Mutate() error = %v, want the error of the mutate func", err)
+	}
+
+	// gives up after MaxConflictRetries
+	srv.InjectFault(msgraphtest.Fault{Method: http.MethodPatch, StatusCode: http.StatusPreconditionFailed})
+	calls = 0
+	_, err = item.Mutate(func(app *testApplication) error {
+		calls++
+		app.DisplayName = "Retried Portal"
+		return nil
+	})
+	if !errors.Is(err, ErrPreconditionFailed) || calls != MaxConflictRetries+1 {
+		t.Errorf("Item.Mutate() error = %v after %d calls, want ErrPreconditionFailed after %d calls", err, calls, MaxConflictRetries+1)
+	}
+	srv.ClearFaults()
+
+	// only the changed properties are sent, not the read-only @odata.etag and changeKey
+	alice := srv.AddUser(msgraphtest.Object{"displayName": "Alice", "userPrincipalName": "alice@contoso.test"})
+	event := srv.AddEvent(alice["id"].(string), "", msgraphtest.Object{"subject": "Team meeting", "location": "Room 1"})
+	eventItem := NewItem[testEvent](g, "/users/"+alice["id"].(string)+"/events/"+event["id"].(string))
+	if _, err := eventItem.Mutate(func(e *testEvent) error {
+		e.Subject = "Team meeting (moved)"
+		e.Location = ""
+		return nil
+	}); err != nil {
+		t.Errorf("Item.Mutate() of an event error = %v", err)
+	}
+	mutated, _ := srv.Get("users/"+alice["id"].(string)+"/events", event["id"].(string))
+	if mutated["subject"] != "Team meeting (moved)" || mutated["location"] != nil {
+		t.Errorf("mutated event = %v, want the subject Team meeting (moved) and the location cleared", mutated)
+	}
+
+	// nothing is updated if nothing has been changed
+	if _, err := eventItem.Mutate(func(e *testEvent) error { return nil }); err != nil {
+		t.Errorf("Item.Mutate() without change error = %v", err)
+	}
+	if unchanged, _ := srv.Get("users/"+alice["id"].(string)+"/events", event["id"].(string)); unchanged["@odata.etag"] != mutated["@odata.etag"] {
+		t.Errorf("Item.Mutate() without change updated the event, @odata.etag = %v, want %v", unchanged["@odata.etag"], mutated["@odata.etag"])
+	}
+
+	// directory objects have no @odata.etag
+	if _, err := NewItem[User](g, "/users/"+alice["id"].(string)).Mutate(func(u *User) error { return nil }); !errors.Is(err, ErrNoETag) {
+		t.Errorf("Item.Mutate() of a user error = %v, want ErrNoETag", err)
+	}
+}
+
+func TestCollection_queryOptions(t *testing.T) {
+	srv, g := newTestClient(t)
+	applications := NewCollection[testApplication](g, "/applications")
+
+	// $select applies to the created resource returned by the API-call
+	created, err := applications.Create(testApplication{DisplayName: "Portal"}, CreateWithSelect("id"))
+	if err != nil || created.ID == "" || created.DisplayName != "" {
+		t.Errorf("Collection.Create() with CreateWithSelect(id) = %v, %v, want only the ID", created, err)
+	}
+	if obj, _ := srv.Get("applications", created.ID); obj["displayName"] != "Portal" {
+		t.Errorf("created application = %v, want displayName Portal", obj)
+	}
+
+	// a top above MaxPageSize loads pages of MaxPageSize results until top results are reached
+	for i := 0; i < MaxPageSize+10; i++ {
+		srv.Add("applications", msgraphtest.Object{"displayName": fmt.Sprintf("App %d", i)})
+	}
+	list, err := applications.List(ListWithTop(MaxPageSize + 5))
+	if err != nil || len(list) != MaxPageSize+5 {
+		t.Errorf("Collection.List(ListWithTop(%d)) returned %d applications, %v, want %d", MaxPageSize+5, len(list), err, MaxPageSize+5)
+	}
+}
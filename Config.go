@@ -0,0 +1,203 @@
+package msgraph
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultConfigEnvPrefix is the prefix of the environment variables read by ConfigFromEnv if no prefix is given
+const DefaultConfigEnvPrefix = "MSGRAPH_"
+
+// Config holds everything required to create a GraphClient. Unlike the GraphClient, decoding a Config from
+// JSON or environment variables has no side effects, neither a file is read nor a Token is requested.
+// Call NewGraphClient to create the GraphClient afterwards.
+//
+// Exactly one credential must be configured: ClientSecret, CertificateFile or ManagedIdentity.
+type Config struct {
+	TenantID      string `json:"tenantId,omitempty" env:"TENANT_ID"`
+	ApplicationID string `json:"applicationId,omitempty" env:"APPLICATION_ID"`
+
+	ClientSecret        string `json:"clientSecret,omitempty" env:"CLIENT_SECRET"`
+	CertificateFile     string `json:"certificateFile,omitempty" env:"CERTIFICATE_FILE"`         // PEM or PKCS #12 file, read by NewGraphClient
+	CertificatePassword string `json:"certificatePassword,omitempty" env:"CERTIFICATE_PASSWORD"` // password of a PKCS #12 CertificateFile
+	ManagedIdentity     bool   `json:"managedIdentity,omitempty" env:"MANAGED_IDENTITY"`         // use the managed identity of the Azure resource, see ManagedIdentityProvider
+	// ManagedIdentityClientID selects a user-assigned managed identity, empty for the system-assigned one
+	ManagedIdentityClientID string `json:"managedIdentityClientId,omitempty" env:"MANAGED_IDENTITY_CLIENT_ID"`
+
+	AzureADAuthEndpoint  string               `json:"azureADAuthEndpoint,omitempty" env:"AZURE_AD_AUTH_ENDPOINT"`  // defaults to AzureADAuthEndpointGlobal
+	ServiceRootEndpoint  string               `json:"serviceRootEndpoint,omitempty" env:"SERVICE_ROOT_ENDPOINT"`   // defaults to ServiceRootEndpointGlobal
+	TokenEndpointVersion TokenEndpointVersion `json:"tokenEndpointVersion,omitempty" env:"TOKEN_ENDPOINT_VERSION"` // defaults to TokenEndpointV2
+	Scopes               []string             `json:"scopes,omitempty" env:"SCOPES"`                               // comma separated in the environment variable
+	APIVersion           string               `json:"apiVersion,omitempty" env:"API_VERSION"`                      // defaults to APIVersion, see ClientWithAPIVersion
+
+	Timeout    Duration `json:"timeout,omitempty" env:"TIMEOUT"`        // timeout of every request, defaults to HttpRequestTimeout
+	MaxRetries int      `json:"maxRetries,omitempty" env:"MAX_RETRIES"` // retries with DefaultRetryPolicy if not 0
+	// LazyInit defers the Token acquisition to the first API-call, see ClientWithLazyInit
+	LazyInit bool `json:"lazyInit,omitempty" env:"LAZY_INIT"`
+}
+
+// ConfigFromEnv reads a Config from the environment variables named like the env tags of the Config fields with
+// the given prefix, e.g. MSGRAPH_TENANT_ID for DefaultConfigEnvPrefix, which is used if prefix is empty. Unset
+// variables leave the field empty.
+func ConfigFromEnv(prefix string) (Config, error) {
+	if prefix == "" {
+		prefix = DefaultConfigEnvPrefix
+	}
+	var config Config
+	value := reflect.ValueOf(&config).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := prefix + field.Tag.Get("env")
+		env, ok := os.LookupEnv(name)
+		if !ok || env == "" {
+			continue
+		}
+		switch target := value.Field(i).Addr().Interface().(type) {
+		case *string:
+			*target = env
+		case *TokenEndpointVersion:
+			*target = TokenEndpointVersion(env)
+		case *[]string:
+			for _, item := range strings.Split(env, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*target = append(*target, item)
+				}
+			}
+		case *bool:
+			parsed, err := strconv.ParseBool(env)
+			if err != nil {
+				return Config{}, fmt.Errorf("cannot parse %v: %w", name, err)
+			}
+			*target = parsed
+		case *int:
+			parsed, err := strconv.Atoi(env)
+			if err != nil {
+				return Config{}, fmt.Errorf("cannot parse %v: %w", name, err)
+			}
+			*target = parsed
+		case *Duration:
+			if err := target.UnmarshalText([]byte(env)); err != nil {
+				return Config{}, fmt.Errorf("cannot parse %v: %w", name, err)
+			}
+		}
+	}
+	return config, nil
+}
+
+// Validate checks that the Config is complete without any network request or file access
+func (c Config) Validate() error {
+	credentials := 0
+	for _, set := range []bool{c.ClientSecret != "", c.CertificateFile != "", c.ManagedIdentity} {
+		if set {
+			credentials++
+		}
+	}
+	switch {
+	case credentials == 0:
+		return fmt.Errorf("no credential configured, set ClientSecret, CertificateFile or ManagedIdentity")
+	case credentials > 1:
+		return fmt.Errorf("more than one credential configured, set only one of ClientSecret, CertificateFile or ManagedIdentity")
+	case !c.ManagedIdentity && c.TenantID == "":
+		return fmt.Errorf("TenantID is empty")
+	case !c.ManagedIdentity && c.ApplicationID == "":
+		return fmt.Errorf("ApplicationID is empty")
+	case c.Timeout < 0:
+		return fmt.Errorf("Timeout %v is negative", c.Timeout)
+	case c.MaxRetries < 0:
+		return fmt.Errorf("MaxRetries %v is negative", c.MaxRetries)
+	}
+	if c.TokenEndpointVersion != "" && c.TokenEndpointVersion != TokenEndpointV1 && c.TokenEndpointVersion != TokenEndpointV2 {
+		return fmt.Errorf("unknown TokenEndpointVersion %v", c.TokenEndpointVersion)
+	}
+	return nil
+}
+
+// NewGraphClient validates the Config and creates a new GraphClient with the configured credential, endpoints
+// and options. Grabs a token unless LazyInit is set. The given opts are applied after the Config, hence they
+// take precedence.
+func (c Config) NewGraphClient(opts ...GraphClientOption) (*GraphClient, error) {
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	var configOpts []GraphClientOption
+	if c.AzureADAuthEndpoint != "" || c.ServiceRootEndpoint != "" {
+		configOpts = append(configOpts, ClientWithEndpoints(c.AzureADAuthEndpoint, c.ServiceRootEndpoint)) // Hint: empty ones default to the global endpoints
+	}
+	if c.TokenEndpointVersion != "" {
+		configOpts = append(configOpts, ClientWithTokenEndpointVersion(c.TokenEndpointVersion))
+	}
+	if len(c.Scopes) > 0 {
+		configOpts = append(configOpts, ClientWithScopes(c.Scopes...))
+	}
+	if c.APIVersion != "" {
+		configOpts = append(configOpts, ClientWithAPIVersion(c.APIVersion))
+	}
+	if c.Timeout > 0 {
+		configOpts = append(configOpts, ClientWithHTTPClient(&http.Client{Timeout: time.Duration(c.Timeout)}))
+	}
+	if c.MaxRetries > 0 {
+		policy := DefaultRetryPolicy
+		policy.MaxRetries = c.MaxRetries
+		configOpts = append(configOpts, ClientWithRetryPolicy(policy))
+	}
+	if c.LazyInit {
+		configOpts = append(configOpts, ClientWithLazyInit())
+	}
+	opts = append(configOpts, opts...)
+
+	switch {
+	case c.ClientSecret != "":
+		return NewGraphClientWithCustomEndpoint(c.TenantID, c.ApplicationID, c.ClientSecret, c.AzureADAuthEndpoint, c.ServiceRootEndpoint, opts...)
+	case c.CertificateFile != "":
+		certificate, err := c.loadCertificate()
+		if err != nil {
+			return nil, err
+		}
+		return NewGraphClientWithCertificate(c.TenantID, c.ApplicationID, certificate, opts...)
+	default:
+		return NewGraphClientWithTokenProvider(NewManagedIdentityProvider(c.ManagedIdentityClientID), opts...)
+	}
+}
+
+// loadCertificate reads the CertificateFile, PEM encoded if it contains a PEM block, PKCS #12 otherwise
+func (c Config) loadCertificate() (ClientCertificate, error) {
+	data, err := ioutil.ReadFile(c.CertificateFile)
+	if err != nil {
+		return ClientCertificate{}, fmt.Errorf("cannot read certificate: %w", err)
+	}
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		return NewClientCertificateFromPEM(data)
+	}
+	return NewClientCertificateFromPKCS12(data, c.CertificatePassword)
+}
+
+// Duration is a time.Duration that is en- and decoded as string like "30s" or "1m30s" in JSON and
+// environment variables
+type Duration time.Duration
+
+// String returns the Duration formatted like time.Duration
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,134 @@
+package msgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConfig_UnmarshalJSON(t *testing.T) {
+	data := `{"tenantId":"tenant","applicationId":"app","clientSecret":"secret","serviceRootEndpoint":"http://127.0.0.1:1",
+		"scopes":["User.Read"],"timeout":"30s","maxRetries":3,"lazyInit":true}`
+	var config Config
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	want := Config{TenantID: "tenant", ApplicationID: "app", ClientSecret: "secret", ServiceRootEndpoint: "http://127.0.0.1:1",
+		Scopes: []string{"User.Read"}, Timeout: Duration(30 * time.Second), MaxRetries: 3, LazyInit: true}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("json.Unmarshal() = %+v, want %+v", config, want)
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Config.Validate() error = %v", err)
+	}
+
+	marshalled, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var roundTrip Config
+	if err := json.Unmarshal(marshalled, &roundTrip); err != nil || !reflect.DeepEqual(roundTrip, want) {
+		t.Errorf("json round trip = %+v, %v, want %+v", roundTrip, err, want)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"TEST_MSGRAPH_TENANT_ID":        "tenant",
+		"TEST_MSGRAPH_APPLICATION_ID":   "app",
+		"TEST_MSGRAPH_MANAGED_IDENTITY": "true",
+		"TEST_MSGRAPH_SCOPES":           "User.Read, offline_access",
+		"TEST_MSGRAPH_TIMEOUT":          "1m",
+		"TEST_MSGRAPH_MAX_RETRIES":      "2",
+	}
+	for key, value := range env {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+	config, err := ConfigFromEnv("TEST_MSGRAPH_")
+	if err != nil {
+		t.Fatalf("ConfigFromEnv() error = %v", err)
+	}
+	want := Config{TenantID: "tenant", ApplicationID: "app", ManagedIdentity: true, Scopes: []string{"User.Read", "offline_access"},
+		Timeout: Duration(time.Minute), MaxRetries: 2}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("ConfigFromEnv() = %+v, want %+v", config, want)
+	}
+
+	os.Setenv("TEST_MSGRAPH_MAX_RETRIES", "many")
+	if _, err := ConfigFromEnv("TEST_MSGRAPH_"); err == nil {
+		t.Errorf("ConfigFromEnv() with invalid MAX_RETRIES error = nil, want error")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "client secret", config: Config{TenantID: "t", ApplicationID: "a", ClientSecret: "s"}},
+		{name: "certificate", config: Config{TenantID: "t", ApplicationID: "a", CertificateFile: "/does/not/exist.pem"}},
+		{name: "managed identity", config: Config{ManagedIdentity: true}},
+		{name: "no credential", config: Config{TenantID: "t", ApplicationID: "a"}, wantErr: true},
+		{name: "two credentials", config: Config{TenantID: "t", ApplicationID: "a", ClientSecret: "s", ManagedIdentity: true}, wantErr: true},
+		{name: "no tenant", config: Config{ApplicationID: "a", ClientSecret: "s"}, wantErr: true},
+		{name: "no application", config: Config{TenantID: "t", ClientSecret: "s"}, wantErr: true},
+		{name: "unknown token endpoint", config: Config{TenantID: "t", ApplicationID: "a", ClientSecret: "s", TokenEndpointVersion: "v3"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_NewGraphClient_lazyInit(t *testing.T) {
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1"}`)
+	})
+	requests := &countingRoundTripper{next: http.DefaultTransport}
+	config := Config{TenantID: "tenant", ApplicationID: "app", ClientSecret: "secret",
+		AzureADAuthEndpoint: srv.URL, ServiceRootEndpoint: srv.URL, LazyInit: true}
+	g, err := config.NewGraphClient(ClientWithRoundTripper(requests))
+	if err != nil {
+		t.Fatalf("Config.NewGraphClient() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&requests.count); got != 0 {
+		t.Errorf("requests after Config.NewGraphClient() = %v, want 0", got)
+	}
+	if _, err := g.GetUser("1"); err != nil {
+		t.Errorf("GraphClient.GetUser() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&requests.count); got != 2 {
+		t.Errorf("requests after GraphClient.GetUser() = %v, want 2, hence the token request and the API-call", got)
+	}
+
+	config.AzureADAuthEndpoint = "http://127.0.0.1:1" // unreachable
+	g, err = config.NewGraphClient()
+	if err != nil {
+		t.Fatalf("Config.NewGraphClient() with unreachable endpoint error = %v, want nil with LazyInit", err)
+	}
+	if _, err := g.GetUser("1"); err == nil {
+		t.Errorf("GraphClient.GetUser() with unreachable endpoint error = nil, want error")
+	}
+
+	if _, err := (Config{TenantID: "tenant"}).NewGraphClient(); err == nil {
+		t.Errorf("Config.NewGraphClient() with invalid Config error = nil, want error")
+	}
+}
+
+func TestGraphClient_UnmarshalJSON_lazyInit(t *testing.T) {
+	var g GraphClient
+	data := `{"TenantID":"tenant","ApplicationID":"app","ClientSecret":"secret","AzureADAuthEndpoint":"http://127.0.0.1:1","LazyInit":true}`
+	if err := json.Unmarshal([]byte(data), &g); err != nil {
+		t.Errorf("GraphClient.UnmarshalJSON() with LazyInit error = %v, want nil without token request", err)
+	}
+}
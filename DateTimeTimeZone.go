@@ -40,7 +40,7 @@ func (s DateTimeTimeZone) NowAdd(duration time.Duration) *DateTimeTimeZone {
 
 func (s *DateTimeTimeZone) MarshalJSON() ([]byte, error) {
 
-	timezone, _ := globalSupportedTimeZones.GetTimeZoneByDisplayName(*s.TimeZone)
+	timezone, _ := getGlobalSupportedTimeZones().GetTimeZoneByDisplayName(*s.TimeZone)
 
 	stringVal := fmt.Sprintf("{ \"dateTime\": \"%v\", \"timeZone\": \"%v\" }",
 		s.DateTime.Format("2006-01-02T15:04:05"), timezone)
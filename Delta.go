@@ -0,0 +1,243 @@
+package msgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// DeltaRemoved represents an item that has been removed since the previous delta query, marked with @removed
+//
+// See https://docs.microsoft.com/en-us/graph/delta-query-overview
+type DeltaRemoved struct {
+	ID     string // the ID of the removed item
+	Reason string // "changed" if the item can be restored, e.g. a soft-deleted user, "deleted" if it is gone for good
+}
+
+// GroupMemberChange represents a member that has been added to or removed from a group since the
+// previous delta query, as returned in members@delta
+type GroupMemberChange struct {
+	GroupID   string // the ID of the group
+	MemberID  string // the ID of the member, e.g. a user or a group
+	ODataType string // the type of the member, e.g. "#microsoft.graph.user"
+	Removed   bool   // true if the member has been removed from the group, false if it has been added
+}
+
+// UsersDelta is the result of a delta query for users, see GraphClient.ListUsersDelta
+type UsersDelta struct {
+	Users     Users          // users that have been added or changed, only the changed properties are set
+	Removed   []DeltaRemoved // users that have been removed
+	DeltaLink string         // pass to ListWithDeltaLink to get the changes since this delta query
+}
+
+// GroupsDelta is the result of a delta query for groups, see GraphClient.ListGroupsDelta
+type GroupsDelta struct {
+	Groups        Groups              // groups that have been added or changed, only the changed properties are set
+	Removed       []DeltaRemoved      // groups that have been removed
+	MemberChanges []GroupMemberChange // members that have been added to or removed from a group
+	DeltaLink     string              // pass to ListWithDeltaLink to get the changes since this delta query
+}
+
+// CalendarEventsDelta is the result of a delta query for a calendar view, see User.ListCalendarViewDelta
+type CalendarEventsDelta struct {
+	CalendarEvents CalendarEvents // events that have been added or changed
+	Removed        []DeltaRemoved // events that have been removed
+	DeltaLink      string         // pass to ListWithDeltaLink to get the changes since this delta query
+}
+
+// deltaItem contains the properties of a delta query item that are needed to tell changed and removed items apart
+type deltaItem struct {
+	ID           string          `json:"id"`
+	Removed      json.RawMessage `json:"@removed"`
+	MembersDelta []struct {
+		ODataType string          `json:"@odata.type"`
+		ID        string          `json:"id"`
+		Removed   json.RawMessage `json:"@removed"`
+	} `json:"members@delta"`
+}
+
+// parseRemoved returns the reason of the given @removed annotation and whether it is set at all. The
+// annotation is either an object {"reason":"deleted"} or, within members@delta, the plain reason.
+func parseRemoved(raw json.RawMessage) (reason string, removed bool) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", false
+	}
+	var annotation struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(raw, &annotation); err == nil {
+		return annotation.Reason, true
+	}
+	_ = json.Unmarshal(raw, &reason)
+	return reason, true
+}
+
+// deltaQuery performs a delta query on the given resource, e.g. /users/delta, or resumes it at the deltaLink
+// given with ListWithDeltaLink. All pages are loaded, the items of all pages and the new @odata.deltaLink
+// are returned.
+//
+// The page size is requested with the Prefer header odata.maxpagesize instead of $top, because $top is
+// not supported by every delta query. It is appended to the preferences given with ListWithHeader, e.g.
+// outlook.timezone.
+func (g *GraphClient) deltaQuery(resource string, reqParams *listQueryOptions) ([]json.RawMessage, string, error) {
+	pageSize := reqParams.queryValues.Get("$top")
+	if pageSize == "" {
+		pageSize = strconv.Itoa(MaxPageSize)
+	}
+	headers := reqParams.Headers().Clone()
+	prefer := "odata.maxpagesize=" + pageSize
+	if existing := headers.Get("Prefer"); existing != "" {
+		prefer = existing + ", " + prefer
+	}
+	headers.Set("Prefer", prefer)
+
+	type deltaPage struct {
+		Items     []json.RawMessage `json:"value"`
+		NextLink  string            `json:"@odata.nextLink"`
+		DeltaLink string            `json:"@odata.deltaLink"`
+	}
+	var page deltaPage
+	var err error
+	if reqParams.deltaLink != "" {
+		err = g.makeSkipTokenApiCall(reqParams.Context(), http.MethodGet, &page, reqParams.deltaLink, headers)
+	} else {
+		var req *http.Request
+		req, err = g.newAPIRequest(resource, http.MethodGet, reqParams, nil)
+		if err == nil {
+			query := req.URL.Query()
+			query.Del("$top")
+			req.URL.RawQuery = query.Encode()
+			req.Header.Set("Prefer", headers.Get("Prefer"))
+			err = g.performSkipTokenRequest(req, &page)
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	items := page.Items
+	for page.DeltaLink == "" {
+		if page.NextLink == "" {
+			return nil, "", fmt.Errorf("delta query of %v returned neither an @odata.nextLink nor an @odata.deltaLink", resource)
+		}
+		nextLink := page.NextLink
+		page = deltaPage{}
+		if err := g.makeSkipTokenApiCall(reqParams.Context(), http.MethodGet, &page, nextLink, headers); err != nil {
+			return nil, "", err
+		}
+		items = append(items, page.Items...)
+	}
+	return items, page.DeltaLink, nil
+}
+
+// splitDeltaItems unmarshals all changed items of a delta query with unmarshalChanged and returns the removed ones
+func splitDeltaItems(items []json.RawMessage, unmarshalChanged func(item json.RawMessage, meta deltaItem) error) ([]DeltaRemoved, error) {
+	var removed []DeltaRemoved
+	for _, item := range items {
+		var meta deltaItem
+		if err := json.Unmarshal(item, &meta); err != nil {
+			return nil, err
+		}
+		if reason, ok := parseRemoved(meta.Removed); ok {
+			removed = append(removed, DeltaRemoved{ID: meta.ID, Reason: reason})
+			continue
+		}
+		if err := unmarshalChanged(item, meta); err != nil {
+			return nil, err
+		}
+	}
+	return removed, nil
+}
+
+// ListUsersDelta returns all users on the first call, respectively only the users that have been added, changed
+// or removed since the delta query of the given ListWithDeltaLink. Persist the returned DeltaLink to get the
+// next changes later on. If the ms graph API does not accept the DeltaLink any more, the returned error
+// matches ErrResyncRequired.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+//
+// Reference: https://docs.microsoft.com/en-us/graph/api/user-delta
+func (g *GraphClient) ListUsersDelta(opts ...ListQueryOption) (UsersDelta, error) {
+	items, deltaLink, err := g.deltaQuery("/users/delta", compileListQueryOptions(opts))
+	if err != nil {
+		return UsersDelta{}, err
+	}
+
+	delta := UsersDelta{DeltaLink: deltaLink}
+	delta.Removed, err = splitDeltaItems(items, func(item json.RawMessage, _ deltaItem) error {
+		var user User
+		if err := json.Unmarshal(item, &user); err != nil {
+			return err
+		}
+		delta.Users = append(delta.Users, user)
+		return nil
+	})
+	if err != nil {
+		return UsersDelta{}, err
+	}
+	delta.Users.setGraphClient(g)
+	return delta, nil
+}
+
+// ListGroupsDelta returns all groups on the first call, respectively only the groups that have been added, changed
+// or removed since the delta query of the given ListWithDeltaLink. Persist the returned DeltaLink to get the
+// next changes later on. If the ms graph API does not accept the DeltaLink any more, the returned error
+// matches ErrResyncRequired.
+//
+// Member changes are only returned if requested with ListWithSelect, e.g. ListWithSelect("displayName,members").
+// A group may be returned multiple times by the ms graph API, each time with a part of its members and
+// properties. These are merged into one group, the properties returned later replace the earlier ones, and
+// all its MemberChanges.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+//
+// Reference: https://docs.microsoft.com/en-us/graph/api/group-delta
+func (g *GraphClient) ListGroupsDelta(opts ...ListQueryOption) (GroupsDelta, error) {
+	items, deltaLink, err := g.deltaQuery("/groups/delta", compileListQueryOptions(opts))
+	if err != nil {
+		return GroupsDelta{}, err
+	}
+
+	delta := GroupsDelta{DeltaLink: deltaLink}
+	var groupIDs []string
+	properties := make(map[string]map[string]json.RawMessage) // the merged properties of each group
+	delta.Removed, err = splitDeltaItems(items, func(item json.RawMessage, meta deltaItem) error {
+		for _, member := range meta.MembersDelta {
+			_, removed := parseRemoved(member.Removed)
+			delta.MemberChanges = append(delta.MemberChanges, GroupMemberChange{
+				GroupID: meta.ID, MemberID: member.ID, ODataType: member.ODataType, Removed: removed,
+			})
+		}
+		var itemProperties map[string]json.RawMessage
+		if err := json.Unmarshal(item, &itemProperties); err != nil {
+			return err
+		}
+		if properties[meta.ID] == nil {
+			groupIDs = append(groupIDs, meta.ID)
+			properties[meta.ID] = itemProperties
+			return nil
+		}
+		for key, value := range itemProperties {
+			properties[meta.ID][key] = value
+		}
+		return nil
+	})
+	if err != nil {
+		return GroupsDelta{}, err
+	}
+	for _, groupID := range groupIDs {
+		data, err := json.Marshal(properties[groupID])
+		if err != nil {
+			return GroupsDelta{}, err
+		}
+		var group Group
+		if err := json.Unmarshal(data, &group); err != nil {
+			return GroupsDelta{}, err
+		}
+		delta.Groups = append(delta.Groups, group)
+	}
+	if err != nil {
+		return GroupsDelta{}, err
+	}
+	delta.Groups.setGraphClient(g)
+	return delta, nil
+}
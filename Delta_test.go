@@ -0,0 +1,96 @@
+package msgraph
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGraphClient_ListUsersDelta(t *testing.T) {
+	var srvURL string
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("$top") != "" || r.Header.Get("Prefer") != "odata.maxpagesize=2" {
+			t.Errorf("delta query %v with Prefer %q, want odata.maxpagesize=2 and no $top", r.URL, r.Header.Get("Prefer"))
+		}
+		switch r.URL.Query().Get("$deltatoken") + r.URL.Query().Get("$skiptoken") {
+		case "":
+			fmt.Fprintf(w, `{"value":[{"id":"1","displayName":"alice"},{"id":"2","@removed":{"reason":"changed"}}],`+
+				`"@odata.nextLink":"%v/v1.0/users/delta?$skiptoken=page2"}`, srvURL)
+		case "page2":
+			fmt.Fprintf(w, `{"value":[{"id":"3","displayName":"bob"}],"@odata.deltaLink":"%v/v1.0/users/delta?$deltatoken=token1"}`, srvURL)
+		case "token1":
+			fmt.Fprintf(w, `{"value":[{"id":"1","@removed":{"reason":"deleted"}}],"@odata.deltaLink":"%v/v1.0/users/delta?$deltatoken=token2"}`, srvURL)
+		default:
+			w.WriteHeader(http.StatusGone)
+			fmt.Fprint(w, `{"error":{"code":"syncStateNotFound","message":"resync required"}}`)
+		}
+	})
+	srvURL = srv.URL
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL)
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+
+	delta, err := g.ListUsersDelta(ListWithPageSize(2))
+	if err != nil {
+		t.Fatalf("GraphClient.ListUsersDelta() error = %v", err)
+	}
+	if len(delta.Users) != 2 || delta.Users[1].DisplayName != "bob" || delta.Users[1].graphClient == nil {
+		t.Errorf("GraphClient.ListUsersDelta() Users = %v, want alice and bob", delta.Users)
+	}
+	if fmt.Sprint(delta.Removed) != "[{2 changed}]" {
+		t.Errorf("GraphClient.ListUsersDelta() Removed = %v, want [{2 changed}]", delta.Removed)
+	}
+
+	delta, err = g.ListUsersDelta(ListWithPageSize(2), ListWithDeltaLink(delta.DeltaLink))
+	if err != nil {
+		t.Fatalf("GraphClient.ListUsersDelta() with DeltaLink error = %v", err)
+	}
+	if len(delta.Users) != 0 || fmt.Sprint(delta.Removed) != "[{1 deleted}]" || delta.DeltaLink != srvURL+"/v1.0/users/delta?$deltatoken=token2" {
+		t.Errorf("GraphClient.ListUsersDelta() with DeltaLink = %+v, want user 1 deleted", delta)
+	}
+
+	_, err = g.ListUsersDelta(ListWithPageSize(2), ListWithDeltaLink(srvURL+"/v1.0/users/delta?$deltatoken=expired"))
+	if !errors.Is(err, ErrResyncRequired) {
+		t.Errorf("GraphClient.ListUsersDelta() with expired DeltaLink error = %v, want ErrResyncRequired", err)
+	}
+}
+
+func TestGraphClient_ListGroupsDelta(t *testing.T) {
+	var srvURL string
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		if want := fmt.Sprintf(`outlook.timezone="UTC", odata.maxpagesize=%d`, MaxPageSize); r.Header.Get("Prefer") != want {
+			t.Errorf("delta query %v with Prefer %q, want %q", r.URL, r.Header.Get("Prefer"), want)
+		}
+		if r.URL.Query().Get("$skiptoken") == "" {
+			fmt.Fprintf(w, `{"value":[{"id":"g1","displayName":"admins","mail":"admins@contoso.com","members@delta":[`+
+				`{"@odata.type":"#microsoft.graph.user","id":"u1"}]}],"@odata.nextLink":"%v/v1.0/groups/delta?$skiptoken=page2"}`, srvURL)
+			return
+		}
+		// Hint: the second part of g1 contains the changed displayName
+		fmt.Fprintf(w, `{"value":[{"id":"g1","displayName":"administrators","members@delta":[{"@odata.type":"#microsoft.graph.user","id":"u2","@removed":"deleted"}]},`+
+			`{"id":"g2","@removed":{"reason":"deleted"}}],"@odata.deltaLink":"%v/v1.0/groups/delta?$deltatoken=token1"}`, srvURL)
+	})
+	srvURL = srv.URL
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL)
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+
+	delta, err := g.ListGroupsDelta(ListWithSelect("displayName,mail,members"), ListWithHeader("Prefer", `outlook.timezone="UTC"`))
+	if err != nil {
+		t.Fatalf("GraphClient.ListGroupsDelta() error = %v", err)
+	}
+	if len(delta.Groups) != 1 || delta.Groups[0].DisplayName != "administrators" || delta.Groups[0].Mail != "admins@contoso.com" ||
+		delta.Groups[0].graphClient == nil {
+		t.Errorf("GraphClient.ListGroupsDelta() Groups = %v, want only the merged administrators", delta.Groups)
+	}
+	want := "[{g1 u1 #microsoft.graph.user false} {g1 u2 #microsoft.graph.user true}]"
+	if fmt.Sprint(delta.MemberChanges) != want {
+		t.Errorf("GraphClient.ListGroupsDelta() MemberChanges = %v, want %v", delta.MemberChanges, want)
+	}
+	if fmt.Sprint(delta.Removed) != "[{g2 deleted}]" || delta.DeltaLink == "" {
+		t.Errorf("GraphClient.ListGroupsDelta() Removed = %v, DeltaLink = %v", delta.Removed, delta.DeltaLink)
+	}
+}
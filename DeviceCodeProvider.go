@@ -0,0 +1,196 @@
+package msgraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// deviceCodeIntervalUnit is the unit of the polling interval returned by the devicecode endpoint
+var deviceCodeIntervalUnit = time.Second
+
+// DeviceCode holds the code the user has to enter at the VerificationURI to sign in, see DeviceCodeProvider
+type DeviceCode struct {
+	UserCode        string    // the code to enter at the VerificationURI
+	VerificationURI string    // the URI to open in a browser on any device, e.g. https://microsoft.com/devicelogin
+	Message         string    // human readable instructions containing the UserCode and VerificationURI, ready to print
+	ExpiresOn       time.Time // the user has to sign in before, otherwise the Token request fails
+}
+
+// DeviceCodeProvider acquires delegated Tokens with the device code flow, e.g. for CLI tools where no browser
+// redirect is possible. On the first Token request, OnDeviceCode is called with a DeviceCode the user has to
+// enter in a browser on any device, meanwhile the token endpoint is polled until the user signed in. Afterwards
+// the Token is refreshed with the refresh token, hence the user only signs in again if it has expired.
+//
+// Request the scope "offline_access" with ClientWithScopes to get a refresh token, it is added automatically
+// for TokenEndpointV2.
+//
+// See https://docs.microsoft.com/en-us/azure/active-directory/develop/v2-oauth2-device-code
+type DeviceCodeProvider struct {
+	TenantID      string // the tenant ID, or "organizations" respectively "common" for multi-tenant applications
+	ApplicationID string
+	// OnDeviceCode is called with the DeviceCode to show to the user, e.g. by printing its Message
+	OnDeviceCode func(code DeviceCode)
+	// OnRefreshTokenChanged is called with the new refresh token whenever it has been issued or rotated, may be nil
+	OnRefreshTokenChanged func(refreshToken string)
+
+	lock          sync.Mutex            // serializes the sign-in, protects refreshTokens and graphClient
+	refreshTokens *RefreshTokenProvider // refreshes the Token after the user signed in, nil before
+	graphClient   *GraphClient          // the graphClient that uses this provider
+}
+
+// NewDeviceCodeProvider creates a new DeviceCodeProvider that calls onDeviceCode to show the DeviceCode to the user
+func NewDeviceCodeProvider(tenantID, applicationID string, onDeviceCode func(code DeviceCode)) *DeviceCodeProvider {
+	return &DeviceCodeProvider{TenantID: tenantID, ApplicationID: applicationID, OnDeviceCode: onDeviceCode}
+}
+
+// setGraphClient sets the graphClient instance that uses this provider
+func (p *DeviceCodeProvider) setGraphClient(g *GraphClient) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.graphClient = g
+	if p.refreshTokens != nil {
+		p.refreshTokens.setGraphClient(g)
+	}
+}
+
+// SetRefreshToken sets a refresh token persisted from a previous sign-in, hence the user does not have to
+// sign in again as long as it is valid
+func (p *DeviceCodeProvider) SetRefreshToken(refreshToken string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if refreshToken == "" {
+		p.refreshTokens = nil
+		return
+	}
+	p.refreshTokens = NewRefreshTokenProvider(p.TenantID, p.ApplicationID, refreshToken)
+	p.refreshTokens.OnRefreshTokenChanged = p.OnRefreshTokenChanged
+	p.refreshTokens.setGraphClient(p.graphClient)
+}
+
+// RefreshToken returns the current refresh token, empty if the user has not signed in yet
+func (p *DeviceCodeProvider) RefreshToken() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.refreshTokens == nil {
+		return ""
+	}
+	return p.refreshTokens.RefreshToken()
+}
+
+// Token implements TokenProvider. Blocks until the user signed in, the DeviceCode expired or ctx is done.
+// The GraphClient does not hold its token lock meanwhile, API-calls that need a new Token wait for the
+// sign-in until their own context is done.
+func (p *DeviceCodeProvider) Token(ctx context.Context) (Token, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.graphClient == nil {
+		return Token{}, errTokenProviderNotGraphClientSourced
+	}
+
+	if p.refreshTokens != nil {
+		token, err := p.refreshTokens.Token(ctx)
+		var graphErr *GraphError
+		if err == nil || !errors.As(err, &graphErr) || graphErr.Code != "invalid_grant" {
+			return token, err
+		}
+		// the refresh token has expired or has been revoked, hence the user has to sign in again
+	}
+
+	token, err := p.signIn(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+	if token.RefreshToken != "" {
+		p.refreshTokens = NewRefreshTokenProvider(p.TenantID, p.ApplicationID, token.RefreshToken)
+		p.refreshTokens.OnRefreshTokenChanged = p.OnRefreshTokenChanged
+		p.refreshTokens.setGraphClient(p.graphClient)
+		if p.OnRefreshTokenChanged != nil {
+			p.OnRefreshTokenChanged(token.RefreshToken)
+		}
+	}
+	return token, nil
+}
+
+// signIn requests a device code, passes it to OnDeviceCode and polls the token endpoint until the user signed in
+func (p *DeviceCodeProvider) signIn(ctx context.Context) (Token, error) {
+	g := p.graphClient
+	endpoint, err := g.oauth2Endpoint(p.TenantID, "devicecode")
+	if err != nil {
+		return Token{}, err
+	}
+	data := url.Values{}
+	data.Add("client_id", p.ApplicationID)
+	g.setTokenAudience(data)
+	if scope := data.Get("scope"); scope != "" && !strings.Contains(scope, "offline_access") {
+		data.Set("scope", scope+" offline_access")
+	}
+
+	var res struct {
+		DeviceCode      string     `json:"device_code"`
+		UserCode        string     `json:"user_code"`
+		VerificationURI string     `json:"verification_uri"`
+		VerificationURL string     `json:"verification_url"` // v1 only
+		ExpiresIn       tokenInt64 `json:"expires_in"`
+		Interval        tokenInt64 `json:"interval"`
+		Message         string     `json:"message"`
+	}
+	if err := g.postOAuth2Form(ctx, endpoint, data, &res); err != nil {
+		return Token{}, fmt.Errorf("cannot request device code: %w", err)
+	}
+	code := DeviceCode{
+		UserCode:        res.UserCode,
+		VerificationURI: res.VerificationURI,
+		Message:         res.Message,
+		ExpiresOn:       time.Now().Add(time.Duration(res.ExpiresIn) * time.Second),
+	}
+	if code.VerificationURI == "" {
+		code.VerificationURI = res.VerificationURL
+	}
+	if p.OnDeviceCode != nil {
+		p.OnDeviceCode(code)
+	}
+
+	interval := time.Duration(res.Interval) * deviceCodeIntervalUnit
+	if interval <= 0 {
+		interval = 5 * deviceCodeIntervalUnit
+	}
+	for {
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Token{}, ctx.Err()
+		case <-timer.C:
+		}
+
+		data := url.Values{}
+		data.Add("client_id", p.ApplicationID)
+		if g.tokenEndpointVersion == TokenEndpointV1 {
+			data.Add("grant_type", "device_code")
+			data.Add("code", res.DeviceCode)
+		} else {
+			data.Add("grant_type", "urn:ietf:params:oauth:grant-type:device_code")
+			data.Add("device_code", res.DeviceCode)
+		}
+		token, err := g.requestToken(ctx, p.TenantID, data)
+		var graphErr *GraphError
+		if err == nil || !errors.As(err, &graphErr) {
+			return token, err
+		}
+		switch graphErr.Code {
+		case "authorization_pending": // the user has not signed in yet
+		case "slow_down":
+			interval += 5 * deviceCodeIntervalUnit
+		default: // e.g. expired_token, authorization_declined or bad_verification_code
+			return Token{}, fmt.Errorf("device code sign-in failed: %w", err)
+		}
+		if time.Now().After(code.ExpiresOn) {
+			return Token{}, fmt.Errorf("device code expired at %v before the user signed in: %w", code.ExpiresOn, err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package msgraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeviceCodeProvider(t *testing.T) {
+	defer func(unit time.Duration) { deviceCodeIntervalUnit = unit }(deviceCodeIntervalUnit)
+	deviceCodeIntervalUnit = time.Millisecond
+
+	var polls int
+	var declined bool
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/devicecode"):
+			if !strings.HasSuffix(r.PostForm.Get("scope"), "/.default offline_access") {
+				t.Errorf("devicecode scope = %v, want offline_access", r.PostForm.Get("scope"))
+			}
+			fmt.Fprint(w, `{"device_code":"dc","user_code":"ABC-123","verification_uri":"https://microsoft.com/devicelogin",`+
+				`"expires_in":900,"interval":1,"message":"To sign in, enter the code ABC-123"}`)
+		case r.PostForm.Get("grant_type") == "refresh_token":
+			writeTestToken(w, "refreshed", r.PostForm.Get("refresh_token")+"-2")
+		case r.PostForm.Get("grant_type") == "urn:ietf:params:oauth:grant-type:device_code" && r.PostForm.Get("device_code") == "dc":
+			polls++
+			var oauthErr string
+			switch {
+			case declined:
+				oauthErr = "authorization_declined"
+			case polls <= 2:
+				oauthErr = "authorization_pending"
+			case polls == 3:
+				oauthErr = "slow_down"
+			}
+			if oauthErr != "" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, `{"error":"%v","error_description":"not signed in"}`, oauthErr)
+				return
+			}
+			writeTestToken(w, "signed-in", "rt")
+		default:
+			t.Errorf("unexpected request %v %v", r.URL.Path, r.PostForm)
+		}
+	}, nil)
+
+	var codes []DeviceCode
+	var persisted []string
+	provider := NewDeviceCodeProvider("organizations", "app", func(code DeviceCode) { codes = append(codes, code) })
+	provider.OnRefreshTokenChanged = func(refreshToken string) { persisted = append(persisted, refreshToken) }
+	g, err := NewGraphClientWithTokenProvider(provider, ClientWithEndpoints(srv.URL, srv.URL))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithTokenProvider() error = %v", err)
+	}
+	if len(codes) != 1 || codes[0].UserCode != "ABC-123" || g.token.AccessToken != "signed-in" || polls != 4 {
+		t.Errorf("device code flow: codes = %v, token = %v after %d polls", codes, g.token.AccessToken, polls)
+	}
+
+	token, err := provider.Token(context.Background())
+	if err != nil || token.AccessToken != "refreshed" || len(codes) != 1 {
+		t.Errorf("DeviceCodeProvider.Token() after sign-in = %v, %v, want refreshed token without sign-in", token.AccessToken, err)
+	}
+	if fmt.Sprint(persisted) != "[rt rt-2]" || provider.RefreshToken() != "rt-2" {
+		t.Errorf("persisted refresh tokens = %v, want [rt rt-2]", persisted)
+	}
+
+	declined = true
+	other := NewDeviceCodeProvider("organizations", "app", nil)
+	_, err = NewGraphClientWithTokenProvider(other, ClientWithEndpoints(srv.URL, srv.URL))
+	var graphErr *GraphError
+	if !errors.As(err, &graphErr) || graphErr.Code != "authorization_declined" {
+		t.Errorf("NewGraphClientWithTokenProvider() with declined sign-in error = %v, want authorization_declined", err)
+	}
+}
+
+func TestDeviceCodeProvider_SetRefreshTokenWhileRefreshing(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		writeTestToken(w, "refreshed", r.PostForm.Get("refresh_token")+"-2")
+	}, nil)
+
+	provider := NewDeviceCodeProvider("organizations", "app", func(code DeviceCode) {
+		t.Errorf("DeviceCodeProvider signed in with %v, want the refresh token to be used", code)
+	})
+	provider.SetRefreshToken("rt")
+	g, err := NewGraphClientWithTokenProvider(provider, ClientWithEndpoints(srv.URL, srv.URL), ClientWithLazyInit())
+	if err != nil {
+		t.Fatalf("NewGraphClientWithTokenProvider() error = %v", err)
+	}
+
+	// Hint: run with -race, GraphClient passes itself to the provider on every refresh
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			g.tokenLock.Lock()
+			err := g.refreshToken(context.Background())
+			g.tokenLock.Unlock()
+			if err != nil {
+				t.Errorf("GraphClient.refreshToken() error = %v", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		provider.SetRefreshToken(fmt.Sprintf("persisted-%d", i))
+	}
+	<-done
+	if !strings.HasPrefix(provider.RefreshToken(), "persisted-") {
+		t.Errorf("DeviceCodeProvider.RefreshToken() = %v, want a persisted refresh token", provider.RefreshToken())
+	}
+}
+
+func TestDeviceCodeProvider_signInDoesNotBlockClient(t *testing.T) {
+	defer func(unit time.Duration) { deviceCodeIntervalUnit = unit }(deviceCodeIntervalUnit)
+	deviceCodeIntervalUnit = time.Millisecond
+
+	var deviceCodes int32
+	requested := make(chan struct{})
+	signedIn := make(chan struct{})
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if strings.HasSuffix(r.URL.Path, "/devicecode") {
+			if atomic.AddInt32(&deviceCodes, 1) == 1 {
+				close(requested)
+			}
+			fmt.Fprint(w, `{"device_code":"dc","user_code":"ABC-123","verification_uri":"https://microsoft.com/devicelogin","expires_in":900,"interval":1}`)
+			return
+		}
+		select {
+		case <-signedIn:
+			writeTestToken(w, "signed-in", "rt")
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"authorization_pending","error_description":"not signed in"}`)
+		}
+	}, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1"}`)
+	})
+	g, err := NewGraphClientWithTokenProvider(NewDeviceCodeProvider("organizations", "app", nil),
+		ClientWithEndpoints(srv.URL, srv.URL), ClientWithLazyInit())
+	if err != nil {
+		t.Fatalf("NewGraphClientWithTokenProvider() error = %v", err)
+	}
+
+	results := make(chan error, 2)
+	go func() { // starts the sign-in
+		_, err := g.GetUser("1")
+		results <- err
+	}()
+	<-requested
+
+	_ = g.String() // must not wait for the sign-in
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := g.GetUser("1", GetWithContext(ctx)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GraphClient.GetUser() during sign-in error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	go func() { // waits for the sign-in
+		_, err := g.GetUser("1")
+		results <- err
+	}()
+	close(signedIn)
+	for i := 0; i < 2; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("GraphClient.GetUser() after sign-in error = %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&deviceCodes); n != 1 {
+		t.Errorf("device codes requested = %d, want a single shared sign-in", n)
+	}
+}
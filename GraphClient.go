@@ -4,22 +4,26 @@
 package msgraph
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
 	"sync"
-	"time"
 )
 
 const (
-	odataSearchParamKey = "$search"
-	odataFilterParamKey = "$filter"
-	odataSelectParamKey = "$select"
+	odataSearchParamKey  = "$search"
+	odataFilterParamKey  = "$filter"
+	odataSelectParamKey  = "$select"
+	odataOrderByParamKey = "$orderby"
+	odataExpandParamKey  = "$expand"
+	odataTopParamKey     = "$top"
+	odataSkipParamKey    = "$skip"
+	odataCountParamKey   = "$count"
+	odataFormatParamKey  = "$format"
 )
 
 // GraphClient represents a msgraph API connection instance.
@@ -27,28 +31,47 @@ const (
 // An instance can also be json-unmarshalled and will immediately be initialized, hence a Token will be
 // grabbed. If grabbing a token fails the JSON-Unmarshal returns an error.
 type GraphClient struct {
-	apiCall sync.Mutex // lock it when performing an API-call to synchronize it
+	tokenLock sync.Mutex // lock it when reading or writing token or tokenRefresh, API-calls themselves run concurrently
 
 	TenantID      string // See https://docs.microsoft.com/en-us/azure/azure-resource-manager/resource-group-create-service-principal-portal#get-tenant-id
 	ApplicationID string // See https://docs.microsoft.com/en-us/azure/azure-resource-manager/resource-group-create-service-principal-portal#get-application-id-and-authentication-key
 	ClientSecret  string // See https://docs.microsoft.com/en-us/azure/azure-resource-manager/resource-group-create-service-principal-portal#get-application-id-and-authentication-key
 
-	token Token // the current token to be used
+	tokenProvider TokenProvider // acquires the tokens, if nil a ClientSecretProvider with the fields above is used
+
+	token        Token         // the current token to be used
+	tokenRefresh *tokenRefresh // the refresh of the token in progress, nil if none, see getToken
 
 	// azureADAuthEndpoint is used for this instance of GraphClient. For available endpoints see https://docs.microsoft.com/en-us/azure/active-directory/develop/authentication-national-cloud#azure-ad-authentication-endpoints
 	azureADAuthEndpoint string
 	// serviceRootEndpoint is the basic API-url used for this instance of GraphClient, namely Microsoft Graph service root endpoints. For available endpoints see https://docs.microsoft.com/en-us/graph/deployments#microsoft-graph-and-graph-explorer-service-root-endpoints.
 	serviceRootEndpoint string
+
+	tokenEndpointVersion TokenEndpointVersion // the version of the token endpoint, TokenEndpointV2 if empty, see ClientWithTokenEndpointVersion
+	scopes               []string             // the scopes requested from TokenEndpointV2, see ClientWithScopes
+	tokenCache           TokenCache           // persists the tokens if not nil, see ClientWithTokenCache
+	lazyInit             bool                 // grab the first token on the first API-call, see ClientWithLazyInit
+	apiVersion           string               // the default API version of all API-calls, APIVersion if empty, see ClientWithAPIVersion
+
+	httpClient  *http.Client // the http.Client used for all requests, see ClientWithHTTPClient
+	retryPolicy RetryPolicy  // the RetryPolicy applied to all requests, see ClientWithRetryPolicy
+	middlewares []Middleware // wrap the token injection, retries and transport of all requests, see ClientWithMiddleware
+	logger      *httpLogger  // logs all requests and responses at debug level if not nil, see ClientWithLogger
+
+	concurrencyLimit chan struct{} // limits the number of parallel in-flight requests if not nil, see ClientWithMaxConcurrency
 }
 
 func (g *GraphClient) String() string {
+	g.tokenLock.Lock()
+	token := g.token
+	g.tokenLock.Unlock()
 	var firstPart, lastPart string
 	if len(g.ClientSecret) > 4 { // if ClientSecret is not initialized prevent a panic slice out of bounds
 		firstPart = g.ClientSecret[0:3]
 		lastPart = g.ClientSecret[len(g.ClientSecret)-3:]
 	}
 	return fmt.Sprintf("GraphClient(TenantID: %v, ApplicationID: %v, ClientSecret: %v...%v, Token validity: [%v - %v])",
-		g.TenantID, g.ApplicationID, firstPart, lastPart, g.token.NotBefore, g.token.ExpiresOn)
+		g.TenantID, g.ApplicationID, firstPart, lastPart, token.NotBefore, token.ExpiresOn)
 }
 
 // NewGraphClient creates a new GraphClient instance with the given parameters
@@ -56,8 +79,10 @@ func (g *GraphClient) String() string {
 // default ms graph API global endpoint is used.
 //
 // This method does not have to be used to create a new GraphClient. If not used, the default global ms Graph API endpoint is used.
-func NewGraphClient(tenantID, applicationID, clientSecret string) (*GraphClient, error) {
-	return NewGraphClientWithCustomEndpoint(tenantID, applicationID, clientSecret, AzureADAuthEndpointGlobal, ServiceRootEndpointGlobal)
+//
+// Optionally pass GraphClientOption, e.g. ClientWithHTTPClient to use a custom *http.Client.
+func NewGraphClient(tenantID, applicationID, clientSecret string, opts ...GraphClientOption) (*GraphClient, error) {
+	return NewGraphClientWithCustomEndpoint(tenantID, applicationID, clientSecret, AzureADAuthEndpointGlobal, ServiceRootEndpointGlobal, opts...)
 }
 
 // NewGraphClientCustomEndpoint creates a new GraphClient instance with the
@@ -70,7 +95,9 @@ func NewGraphClient(tenantID, applicationID, clientSecret string) (*GraphClient,
 //
 // Returns an error if the token cannot be initialized. This func does not have
 // to be used to create a new GraphClient.
-func NewGraphClientWithCustomEndpoint(tenantID, applicationID, clientSecret string, azureADAuthEndpoint string, serviceRootEndpoint string) (*GraphClient, error) {
+//
+// Optionally pass GraphClientOption, e.g. ClientWithHTTPClient to use a custom *http.Client.
+func NewGraphClientWithCustomEndpoint(tenantID, applicationID, clientSecret string, azureADAuthEndpoint string, serviceRootEndpoint string, opts ...GraphClientOption) (*GraphClient, error) {
 	g := GraphClient{
 		TenantID:            tenantID,
 		ApplicationID:       applicationID,
@@ -78,9 +105,48 @@ func NewGraphClientWithCustomEndpoint(tenantID, applicationID, clientSecret stri
 		azureADAuthEndpoint: azureADAuthEndpoint,
 		serviceRootEndpoint: serviceRootEndpoint,
 	}
-	g.apiCall.Lock()         // lock because we will refresh the token
-	defer g.apiCall.Unlock() // unlock after token refresh
-	return &g, g.refreshToken()
+	g.applyOptions(opts)
+	return &g, g.initToken()
+}
+
+// NewGraphClientWithCertificate creates a new GraphClient instance that authenticates the application with the
+// given ClientCertificate instead of a ClientSecret and grabs a token. Returns an error if the token cannot be
+// initialized. The default ms graph API global endpoints are used unless ClientWithEndpoints is passed.
+//
+// See https://docs.microsoft.com/en-us/azure/active-directory/develop/active-directory-certificate-credentials
+func NewGraphClientWithCertificate(tenantID, applicationID string, certificate ClientCertificate, opts ...GraphClientOption) (*GraphClient, error) {
+	g, err := NewGraphClientWithTokenProvider(NewCertificateProvider(tenantID, applicationID, certificate), opts...)
+	g.TenantID = tenantID
+	g.ApplicationID = applicationID
+	return g, err
+}
+
+// NewGraphClientWithTokenProvider creates a new GraphClient instance that acquires its tokens from the given
+// TokenProvider, e.g. a RefreshTokenProvider for delegated permissions, and grabs a token. Returns an error
+// if the token cannot be initialized. The default ms graph API global endpoints are used unless
+// ClientWithEndpoints is passed.
+//
+// A built-in TokenProvider uses the endpoints and the http.Client of the GraphClient, hence it must not be
+// shared with another GraphClient.
+func NewGraphClientWithTokenProvider(provider TokenProvider, opts ...GraphClientOption) (*GraphClient, error) {
+	g := GraphClient{
+		tokenProvider:       provider,
+		azureADAuthEndpoint: AzureADAuthEndpointGlobal,
+		serviceRootEndpoint: ServiceRootEndpointGlobal,
+	}
+	g.applyOptions(opts)
+	return &g, g.initToken()
+}
+
+// initToken grabs the first Token, unless ClientWithLazyInit has been passed. In that case the first API-call
+// grabs the Token.
+func (g *GraphClient) initToken() error {
+	if g.lazyInit {
+		return nil
+	}
+	g.tokenLock.Lock()         // lock because we will refresh the token
+	defer g.tokenLock.Unlock() // unlock after token refresh
+	return g.refreshToken(context.Background())
 }
 
 // makeSureURLsAreSet ensures that the two fields g.azureADAuthEndpoint and g.serviceRootEndpoint
@@ -95,41 +161,70 @@ func (g *GraphClient) makeSureURLsAreSet() {
 	}
 }
 
-// refreshToken refreshes the current Token. Grabs a new one and saves it within the GraphClient instance
-func (g *GraphClient) refreshToken() error {
+// refreshToken refreshes the current Token. Grabs a new one from the TokenProvider and saves it within the
+// GraphClient instance. The caller must hold g.tokenLock.
+func (g *GraphClient) refreshToken(ctx context.Context) error {
 	g.makeSureURLsAreSet()
-	if g.TenantID == "" {
-		return fmt.Errorf("tenant ID is empty")
-	}
-	resource := fmt.Sprintf("/%v/oauth2/token", g.TenantID)
-	data := url.Values{}
-	data.Add("grant_type", "client_credentials")
-	data.Add("client_id", g.ApplicationID)
-	data.Add("client_secret", g.ClientSecret)
-	data.Add("resource", g.serviceRootEndpoint)
-
-	u, err := url.ParseRequestURI(g.azureADAuthEndpoint)
+	token, err := g.fetchToken(ctx, g.getTokenProvider())
 	if err != nil {
-		return fmt.Errorf("unable to parse URI: %v", err)
+		return err
 	}
+	g.token = token
+	return nil
+}
 
-	u.Path = resource
-	req, err := http.NewRequest("POST", u.String(), bytes.NewBufferString(data.Encode()))
-
+// fetchToken grabs a new Token from the token cache or else from provider and stores it in the token cache,
+// but not within the GraphClient instance. Does not need g.tokenLock, hence an interactive TokenProvider like
+// the DeviceCodeProvider does not block other callers. The endpoint URLs must be set.
+func (g *GraphClient) fetchToken(ctx context.Context, provider TokenProvider) (Token, error) {
+	var cacheKey string
+	if g.tokenCache != nil {
+		cacheKey = g.tokenCacheKey(provider)
+	}
+	if cacheKey != "" {
+		if cached, ok, err := g.tokenCache.Load(cacheKey); err == nil && ok {
+			if user, ok := provider.(cachedRefreshTokenUser); ok && cached.RefreshToken != "" {
+				user.useCachedRefreshToken(cached.RefreshToken)
+			}
+			if !cached.WantsToBeRefreshed() {
+				return cached, nil
+			}
+		}
+	}
+	newToken, err := provider.Token(tokenRequestContext(ctx))
 	if err != nil {
-		return fmt.Errorf("HTTP Request Error: %v", err)
+		return Token{}, fmt.Errorf("error on getting msgraph Token: %w", err)
+	}
+	if cacheKey != "" {
+		g.tokenCache.Store(cacheKey, newToken) // Hint: errors are ignored, the next refresh requests a new Token
 	}
+	return newToken, nil
+}
 
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Add("Content-Length", strconv.Itoa(len(data.Encode())))
+// getTokenProvider returns the TokenProvider of the GraphClient. If none has been set, e.g. because the
+// GraphClient has been created with NewGraphClient or json-unmarshalled, a ClientSecretProvider with the
+// TenantID, ApplicationID and ClientSecret of the GraphClient is used. The caller must hold g.tokenLock.
+func (g *GraphClient) getTokenProvider() TokenProvider {
+	provider := g.tokenProvider
+	if provider == nil { // Hint: created on every refresh, hence changes of the exported fields are respected
+		provider = NewClientSecretProvider(g.TenantID, g.ApplicationID, g.ClientSecret)
+	}
+	if builtIn, ok := provider.(graphClientTokenProvider); ok {
+		builtIn.setGraphClient(g)
+	}
+	return provider
+}
 
-	var newToken Token
-	err = g.performRequest(req, &newToken) // perform the prepared request
-	if err != nil {
-		return fmt.Errorf("error on getting msgraph Token: %v", err)
+// getAPIVersion returns the API version of a request, the one of the query options if set, otherwise the one
+// of the GraphClient, see ClientWithAPIVersion
+func (g *GraphClient) getAPIVersion(reqParams getRequestParams) string {
+	if version := reqParams.APIVersion(); version != "" {
+		return version
+	}
+	if g.apiVersion != "" {
+		return g.apiVersion
 	}
-	g.token = newToken
-	return err
+	return APIVersion
 }
 
 // makeGETAPICall performs an API-Call to the msgraph API.
@@ -152,38 +247,92 @@ func (g *GraphClient) makeDELETEAPICall(apiCall string, reqParams getRequestPara
 	return g.makeAPICall(apiCall, http.MethodDelete, reqParams, nil, v)
 }
 
-// makeAPICall performs an API-Call to the msgraph API. This func uses sync.Mutex to synchronize all API-calls.
+// getToken returns a valid Token and refreshes it before if it's not valid anymore. Also makes sure the
+// endpoint URLs are set. The refresh runs without holding g.tokenLock, concurrent callers wait for and share
+// it until their ctx is done. Hence a slow refresh, e.g. the interactive sign-in of a DeviceCodeProvider,
+// only blocks the callers that need a new Token.
+func (g *GraphClient) getToken(ctx context.Context) (Token, error) {
+	g.tokenLock.Lock()
+	g.makeSureURLsAreSet()
+	if !g.token.WantsToBeRefreshed() { // Token still valid?
+		token := g.token
+		g.tokenLock.Unlock()
+		return token, nil
+	}
+	if refresh := g.tokenRefresh; refresh != nil { // another caller is refreshing the Token already
+		g.tokenLock.Unlock()
+		select {
+		case <-refresh.done:
+		case <-ctx.Done():
+			return Token{}, ctx.Err()
+		}
+		if refresh.err != nil {
+			return Token{}, refresh.err
+		}
+		g.tokenLock.Lock()
+		defer g.tokenLock.Unlock()
+		return g.token, nil
+	}
+	refresh := &tokenRefresh{done: make(chan struct{})}
+	g.tokenRefresh = refresh
+	provider := g.getTokenProvider()
+	g.tokenLock.Unlock()
+
+	token, err := g.fetchToken(ctx, provider)
+
+	g.tokenLock.Lock()
+	defer g.tokenLock.Unlock()
+	if err == nil {
+		g.token = token
+	}
+	g.tokenRefresh = nil
+	refresh.err = err
+	close(refresh.done)
+	return token, err
+}
+
+// tokenRefresh is a refresh of the Token of a GraphClient in progress, see getToken
+type tokenRefresh struct {
+	done chan struct{} // closed when the refresh has finished
+	err  error         // the result of the refresh, set before done is closed
+}
+
+// makeAPICall performs an API-Call to the msgraph API. API-calls of the same GraphClient may run
+// concurrently, only the token refresh is synchronized.
 //
 // Parameter httpMethod may be http.MethodGet, http.MethodPost or http.MethodPatch
 //
 // Parameter body may be nil to not provide any content - e.g. when using a http GET request.
 func (g *GraphClient) makeAPICall(apiCall string, httpMethod string, reqParams getRequestParams, body io.Reader, v interface{}) error {
-	g.makeSureURLsAreSet()
-	g.apiCall.Lock()
-	defer g.apiCall.Unlock() // unlock when the func returns
-	// Check token
-	if g.token.WantsToBeRefreshed() { // Token not valid anymore?
-		err := g.refreshToken()
-		if err != nil {
-			return err
-		}
+	req, err := g.newAPIRequest(apiCall, httpMethod, reqParams, body)
+	if err != nil {
+		return err
 	}
+	return g.performRequest(req, reqParams, v)
+}
+
+// newAPIRequest prepares a http.Request for an API-Call to the msgraph API including all query parameters
+// and headers of reqParams. The token is added when the request is performed. See makeAPICall for the parameters.
+func (g *GraphClient) newAPIRequest(apiCall string, httpMethod string, reqParams getRequestParams, body io.Reader) (*http.Request, error) {
+	g.tokenLock.Lock()
+	g.makeSureURLsAreSet()
+	g.tokenLock.Unlock()
 
 	reqURL, err := url.ParseRequestURI(g.serviceRootEndpoint)
 	if err != nil {
-		return fmt.Errorf("unable to parse URI %v: %v", g.serviceRootEndpoint, err)
+		return nil, fmt.Errorf("unable to parse URI %v: %v", g.serviceRootEndpoint, err)
 	}
 
 	// Add Version to API-Call, the leading slash is always added by the calling func
-	reqURL.Path = "/" + APIVersion + apiCall
+	reqURL.Path = "/" + g.getAPIVersion(reqParams) + apiCall
 
-	req, err := http.NewRequestWithContext(reqParams.Context(), httpMethod, reqURL.String(), body)
+	// the Authorization header is set by the innermost Handler, see authorizeRequest
+	req, err := http.NewRequestWithContext(withAuthorization(reqParams.Context()), httpMethod, reqURL.String(), body)
 	if err != nil {
-		return fmt.Errorf("HTTP request error: %v", err)
+		return nil, fmt.Errorf("HTTP request error: %v", err)
 	}
 
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", g.token.GetAccessToken())
 
 	for key, vals := range reqParams.Headers() {
 		for idx := range vals {
@@ -191,38 +340,40 @@ func (g *GraphClient) makeAPICall(apiCall string, httpMethod string, reqParams g
 		}
 	}
 
+	req.URL.RawQuery = encodeQueryParams(httpMethod, reqParams) // set query parameters
+
+	return req, nil
+}
+
+// encodeQueryParams returns the URL-encoded query parameters of reqParams for an API-call with the given httpMethod
+func encodeQueryParams(httpMethod string, reqParams getRequestParams) string {
 	var getParams = reqParams.Values()
 
-	if httpMethod == http.MethodGet {
-		// TODO: Improve performance with using $skip & paging instead of retrieving all results with $top
-		// TODO: MaxPageSize is currently 999, if there are any time more than 999 entries this will make the program unpredictable... hence start to use paging (!)
+	if httpMethod == http.MethodGet && getParams.Get("$top") == "" {
+		// request the biggest possible pages unless a page size has been set, e.g. with ListWithPageSize
 		getParams.Add("$top", strconv.Itoa(MaxPageSize))
 	}
-	req.URL.RawQuery = getParams.Encode() // set query parameters
-
-	return g.performRequest(req, v)
+	return getParams.Encode()
 }
 
 // makeSkipTokenAPICall performs an API-Call to the msgraph API.
 //
-// Gets the results of the page specified by the skip token
-func (g *GraphClient) makeSkipTokenApiCall(httpMethod string, v interface{}, skipToken string) error {
-
-	// Check token
-	if g.token.WantsToBeRefreshed() { // Token not valid anymore?
-		err := g.refreshToken()
-		if err != nil {
-			return err
-		}
-	}
-
-	req, err := http.NewRequest(httpMethod, skipToken, nil)
+// Gets the results of the page specified by the skip token, the given context.Context is used for the request.
+// Parameter headers may be nil or contain additional headers, e.g. ConsistencyLevel.
+func (g *GraphClient) makeSkipTokenApiCall(ctx context.Context, httpMethod string, v interface{}, skipToken string, headers http.Header) error {
+	// the Authorization header is set by the innermost Handler, see authorizeRequest
+	req, err := http.NewRequestWithContext(withAuthorization(ctx), httpMethod, skipToken, nil)
 	if err != nil {
 		return fmt.Errorf("HTTP request error: %v", err)
 	}
 
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", g.token.GetAccessToken())
+
+	for key, vals := range headers {
+		for idx := range vals {
+			req.Header.Add(key, vals[idx])
+		}
+	}
 
 	return g.performSkipTokenRequest(req, v)
 }
@@ -230,27 +381,9 @@ func (g *GraphClient) makeSkipTokenApiCall(httpMethod string, v interface{}, ski
 // performSkipTokenRequest performs a pre-prepared http.Request and does the proper error-handling for it.
 // does a json.Unmarshal into the v interface{} and returns the error of it if everything went well so far.
 func (g *GraphClient) performSkipTokenRequest(req *http.Request, v interface{}) error {
-	httpClient := &http.Client{
-		Timeout: time.Second * 10,
-	}
-
-	resp, err := httpClient.Do(req)
+	_, body, err := g.doRequest(req) // retries according to the RetryPolicy, returns a GraphError if the StatusCode is not OK
 	if err != nil {
-		return fmt.Errorf("HTTP response error: %v of http.Request: %v", err, req.URL)
-	}
-	defer resp.Body.Close() // close body when func returns
-
-	body, err := ioutil.ReadAll(resp.Body) // read body first to append it to the error (if any)
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		// Hint: this will mostly be the case if the tenant ID cannot be found, the Application ID cannot be found or the clientSecret is incorrect.
-		// The cause will be described in the body, hence we have to return the body too for proper error-analysis
-		return fmt.Errorf("StatusCode is not OK: %v. Body: %v ", resp.StatusCode, string(body))
-	}
-
-	// fmt.Println("Body: ", string(body))
-
-	if err != nil {
-		return fmt.Errorf("HTTP response read error: %v of http.Request: %v", err, req.URL)
+		return err
 	}
 
 	return json.Unmarshal(body, &v) // return the error of the json unmarshal
@@ -258,56 +391,69 @@ func (g *GraphClient) performSkipTokenRequest(req *http.Request, v interface{})
 
 // performRequest performs a pre-prepared http.Request and does the proper error-handling for it.
 // does a json.Unmarshal into the v interface{} and returns the error of it if everything went well so far.
-func (g *GraphClient) performRequest(req *http.Request, v interface{}) error {
-	httpClient := &http.Client{
-		Timeout: HttpRequestTimeout,
-	}
-	resp, err := httpClient.Do(req)
+func (g *GraphClient) performRequest(req *http.Request, reqParams getRequestParams, v interface{}) error {
+	// Hint: a GraphError will mostly be returned if the tenant ID cannot be found, the Application ID cannot be found or the clientSecret is incorrect.
+	// The cause will be described in the body, hence it's parsed into the GraphError for proper error-analysis
+	_, body, err := g.doRequest(req) // retries according to the RetryPolicy
 	if err != nil {
-		return fmt.Errorf("HTTP response error: %v of http.Request: %v", err, req.URL)
-	}
-	defer resp.Body.Close() // close body when func returns
-
-	body, err := ioutil.ReadAll(resp.Body) // read body first to append it to the error (if any)
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		// Hint: this will mostly be the case if the tenant ID cannot be found, the Application ID cannot be found or the clientSecret is incorrect.
-		// The cause will be described in the body, hence we have to return the body too for proper error-analysis
-		return fmt.Errorf("StatusCode is not OK: %v. Body: %v ", resp.StatusCode, string(body))
-	}
-
-	if err != nil {
-		return fmt.Errorf("HTTP response read error: %v of http.Request: %v", err, req.URL)
+		return err
 	}
 
 	// no content returned when http PATCH or DELETE is used, e.g. User.DeleteUser()
 	if req.Method == http.MethodDelete || req.Method == http.MethodPatch {
 		return nil
 	}
+	body, err = g.followNextLinks(req.Context(), req.Method, body, reqParams)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, &v) // return the error of the json unmarshal
+}
+
+// followNextLinks loads all further pages if the given response body contains an @odata.nextLink and
+// returns a body with the "value" of all pages combined. Returns the body as-is if there is no nextLink.
+//
+// If reqParams are listQueryOptions, their headers are sent with every page, at most ListWithTop results
+// are returned and the @odata.count is stored as requested by ListWithCount. reqParams may be nil.
+func (g *GraphClient) followNextLinks(ctx context.Context, httpMethod string, body []byte, reqParams getRequestParams) ([]byte, error) {
 	type skipTokenCallData struct {
 		Data      []json.RawMessage `json:"value"`
 		SkipToken string            `json:"@odata.nextLink"`
+		Count     *int              `json:"@odata.count"`
 	}
 	res := skipTokenCallData{}
 
-	err = json.Unmarshal(body, &res)
+	err := json.Unmarshal(body, &res)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var headers http.Header
+	var top int
+	if listParams, ok := reqParams.(*listQueryOptions); ok {
+		headers, top = listParams.Headers(), listParams.top
+		if listParams.count != nil && res.Count != nil {
+			*listParams.count = *res.Count
+		}
 	}
 
-	if res.SkipToken == "" {
-		return json.Unmarshal(body, &v) // return the error of the json unmarshal
+	if res.SkipToken == "" && (top <= 0 || len(res.Data) <= top) {
+		return body, nil
 	}
 
 	data := res.Data
-	for res.SkipToken != "" {
+	for res.SkipToken != "" && (top <= 0 || len(data) < top) {
 		skipToken := res.SkipToken
 		res = skipTokenCallData{}
-		err := g.makeSkipTokenApiCall(req.Method, &res, skipToken)
+		err := g.makeSkipTokenApiCall(ctx, httpMethod, &res, skipToken, headers)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		data = append(data, res.Data...)
 	}
+	if top > 0 && len(data) > top {
+		data = data[:top]
+	}
 
 	var dataBytes []byte
 
@@ -317,12 +463,15 @@ func (g *GraphClient) performRequest(req *http.Request, v interface{}) error {
 		dataBytes = append(dataBytes, b...)
 		dataBytes = append(dataBytes, []byte(",")...)
 	}
+	if len(dataBytes) > 0 {
+		dataBytes = dataBytes[:len(dataBytes)-1] // skip last comma
+	}
 
-	toReturn := []byte(`{"value":[`)                             //add missing "value" tag
-	toReturn = append(toReturn, dataBytes[:len(dataBytes)-1]...) //append previous data and skip last comma
+	toReturn := []byte(`{"value":[`) //add missing "value" tag
+	toReturn = append(toReturn, dataBytes...)
 	toReturn = append(toReturn, []byte("]}")...)
 
-	return json.Unmarshal(toReturn, &v) // return the error of the json unmarshal
+	return toReturn, nil
 }
 
 // ListUsers returns a list of all users
@@ -330,13 +479,7 @@ func (g *GraphClient) performRequest(req *http.Request, v interface{}) error {
 //
 // Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user_list
 func (g *GraphClient) ListUsers(opts ...ListQueryOption) (Users, error) {
-	resource := "/users"
-	var marsh struct {
-		Users Users `json:"value"`
-	}
-	err := g.makeGETAPICall(resource, compileListQueryOptions(opts), &marsh)
-	marsh.Users.setGraphClient(g)
-	return marsh.Users, err
+	return NewCollection[User](g, "/users").List(opts...)
 }
 
 // ListGroups returns a list of all groups
@@ -344,16 +487,25 @@ func (g *GraphClient) ListUsers(opts ...ListQueryOption) (Users, error) {
 //
 // Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/group_list
 func (g *GraphClient) ListGroups(opts ...ListQueryOption) (Groups, error) {
-	resource := "/groups"
+	return NewCollection[Group](g, "/groups").List(opts...)
+}
 
-	var reqParams = compileListQueryOptions(opts)
+// IterateUsers returns an UsersIterator to load all users page by page instead of loading all of them
+// at once like ListUsers. Use ListWithPageSize to set the page size and ListWithNextLink to resume.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+//
+// Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user_list
+func (g *GraphClient) IterateUsers(opts ...ListQueryOption) *UsersIterator {
+	return &UsersIterator{NewCollection[User](g, "/users").Iterate(opts...)}
+}
 
-	var marsh struct {
-		Groups Groups `json:"value"`
-	}
-	err := g.makeGETAPICall(resource, reqParams, &marsh)
-	marsh.Groups.setGraphClient(g)
-	return marsh.Groups, err
+// IterateGroups returns a GroupsIterator to load all groups page by page instead of loading all of them
+// at once like ListGroups. Use ListWithPageSize to set the page size and ListWithNextLink to resume.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+//
+// Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/group_list
+func (g *GraphClient) IterateGroups(opts ...ListQueryOption) *GroupsIterator {
+	return &GroupsIterator{NewCollection[Group](g, "/groups").Iterate(opts...)}
 }
 
 // GetUser returns the user object associated to the given user identified by either
@@ -362,10 +514,7 @@ func (g *GraphClient) ListGroups(opts ...ListQueryOption) (Groups, error) {
 //
 // Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user_get
 func (g *GraphClient) GetUser(identifier string, opts ...GetQueryOption) (User, error) {
-	resource := fmt.Sprintf("/users/%v", identifier)
-	user := User{graphClient: g}
-	err := g.makeGETAPICall(resource, compileGetQueryOptions(opts), &user)
-	return user, err
+	return NewCollection[User](g, "/users").Get(identifier, opts...)
 }
 
 // GetGroup returns the group object identified by the given groupID.
@@ -373,31 +522,22 @@ func (g *GraphClient) GetUser(identifier string, opts ...GetQueryOption) (User,
 //
 // Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/group_get
 func (g *GraphClient) GetGroup(groupID string, opts ...GetQueryOption) (Group, error) {
-	resource := fmt.Sprintf("/groups/%v", groupID)
-	group := Group{graphClient: g}
-	err := g.makeGETAPICall(resource, compileGetQueryOptions(opts), &group)
-	return group, err
+	return NewCollection[Group](g, "/groups").Get(groupID, opts...)
 }
 
 // CreateUser creates a new user given a user object and returns and updated object
 // Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user-post-users
 func (g *GraphClient) CreateUser(userInput User, opts ...CreateQueryOption) (User, error) {
-	user := User{graphClient: g}
-	bodyBytes, err := json.Marshal(userInput)
-	if err != nil {
-		return user, err
-	}
-
-	reader := bytes.NewReader(bodyBytes)
-	err = g.makePOSTAPICall("/users", compileCreateQueryOptions(opts), reader, &user)
-
-	return user, err
+	return NewCollection[User](g, "/users").Create(userInput, opts...)
 }
 
 // UnmarshalJSON implements the json unmarshal to be used by the json-library.
 // This method additionally to loading the TenantID, ApplicationID and ClientSecret
 // immediately gets a Token from msgraph (hence initialize this GraphAPI instance)
-// and returns an error if any of the data provided is incorrect or the token cannot be acquired
+// and returns an error if any of the data provided is incorrect or the token cannot be acquired.
+//
+// If LazyInit is true, the Token is grabbed by the first API-call instead. Use Config to decode
+// the settings without creating a GraphClient at all.
 func (g *GraphClient) UnmarshalJSON(data []byte) error {
 	tmp := struct {
 		TenantID            string
@@ -405,6 +545,7 @@ func (g *GraphClient) UnmarshalJSON(data []byte) error {
 		ClientSecret        string
 		AzureADAuthEndpoint string
 		ServiceRootEndpoint string
+		LazyInit            bool
 	}{}
 
 	err := json.Unmarshal(data, &tmp)
@@ -427,11 +568,11 @@ func (g *GraphClient) UnmarshalJSON(data []byte) error {
 	g.azureADAuthEndpoint = tmp.AzureADAuthEndpoint
 	g.serviceRootEndpoint = tmp.ServiceRootEndpoint
 	g.makeSureURLsAreSet()
+	g.lazyInit = tmp.LazyInit
 
 	// get a token and return the error (if any)
-	err = g.refreshToken()
-	if err != nil {
-		return fmt.Errorf("can't get Token: %v", err)
+	if err := g.initToken(); err != nil {
+		return fmt.Errorf("can't get Token: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,65 @@
+package msgraph
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGraphClient_concurrentAPICalls(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            []GraphClientOption
+		wantMaxInFlight int32
+	}{
+		{name: "unlimited", wantMaxInFlight: 8},
+		{name: "ClientWithMaxConcurrency(2)", opts: []GraphClientOption{ClientWithMaxConcurrency(2)}, wantMaxInFlight: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tokenRequests, inFlight, maxInFlight int32
+			srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&tokenRequests, 1)
+				time.Sleep(50 * time.Millisecond) // give concurrent callers the chance to request a token too
+				writeTestToken(w, "test-token", "")
+			}, func(w http.ResponseWriter, r *http.Request) {
+				current := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+				for {
+					max := atomic.LoadInt32(&maxInFlight)
+					if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+						break
+					}
+				}
+				time.Sleep(100 * time.Millisecond)
+				fmt.Fprint(w, `{"id":"1"}`)
+			})
+			// a GraphClient without a token yet, hence all concurrent callers want to refresh it
+			g := &GraphClient{TenantID: "tenant", ApplicationID: "app", ClientSecret: "secret",
+				azureADAuthEndpoint: srv.URL, serviceRootEndpoint: srv.URL}
+			g.applyOptions(tt.opts)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := g.GetUser("1"); err != nil {
+						t.Errorf("GraphClient.GetUser() error = %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := atomic.LoadInt32(&tokenRequests); got != 1 {
+				t.Errorf("token has been requested %d times, want 1", got)
+			}
+			if got := atomic.LoadInt32(&maxInFlight); got != tt.wantMaxInFlight {
+				t.Errorf("max. parallel requests = %d, want %d", got, tt.wantMaxInFlight)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package msgraph
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SerenityITS-Development/go-msgraph/internal/redact"
+)
+
+// DefaultLogBodySize is the maximum number of bytes of a request or response body that are logged by default,
+// see LogWithMaxBodySize
+const DefaultLogBodySize = 4096
+
+// LoggerOption configures the logging of a GraphClient, see ClientWithLogger
+type LoggerOption func(l *httpLogger)
+
+var (
+	// LogWithRedactedHeaders - redact the given headers in addition to Authorization, X-Identity-Header,
+	// Cookie and Set-Cookie
+	LogWithRedactedHeaders = func(headers ...string) LoggerOption {
+		return func(l *httpLogger) {
+			l.redactor.AddHeaders(headers...)
+		}
+	}
+
+	// LogWithRedactedFormFields - redact the given fields of form-encoded bodies in addition to the credentials
+	// sent to the token endpoint, e.g. client_secret and refresh_token
+	LogWithRedactedFormFields = func(fields ...string) LoggerOption {
+		return func(l *httpLogger) {
+			l.redactor.AddFormFields(fields...)
+		}
+	}
+
+	// LogWithRedactedJSONProperties - redact the given properties of JSON bodies in addition to the tokens
+	// and passwordProfile.password. Nested properties are separated by dots, e.g. "passwordProfile.password",
+	// and match at any depth, hence also within arrays and the requests of a $batch.
+	LogWithRedactedJSONProperties = func(properties ...string) LoggerOption {
+		return func(l *httpLogger) {
+			l.redactor.AddJSONProperties(properties...)
+		}
+	}
+
+	// LogWithMaxBodySize - log at most the given number of bytes of request and response bodies instead of
+	// DefaultLogBodySize. 0 disables logging bodies, a negative size logs them completely.
+	LogWithMaxBodySize = func(size int) LoggerOption {
+		return func(l *httpLogger) {
+			l.maxBodySize = size
+		}
+	}
+)
+
+// httpLogger logs the requests and responses of a GraphClient at debug level, see ClientWithLogger.
+// The methods may be called on nil, which logs nothing.
+type httpLogger struct {
+	logger      *slog.Logger
+	redactor    *redact.Redactor
+	maxBodySize int
+}
+
+// newHTTPLogger returns a httpLogger that logs to the given *slog.Logger with the default redactions
+func newHTTPLogger(logger *slog.Logger, opts []LoggerOption) *httpLogger {
+	l := &httpLogger{
+		logger:      logger,
+		redactor:    redact.New(),
+		maxBodySize: DefaultLogBodySize,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// enabled returns true if requests of the given http.Request shall be logged
+func (l *httpLogger) enabled(req *http.Request) bool {
+	return l != nil && l.logger.Enabled(req.Context(), slog.LevelDebug)
+}
+
+// logRequest logs the given http.Request right before it's sent
+func (l *httpLogger) logRequest(req *http.Request) {
+	if !l.enabled(req) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", redact.URL(req.URL)),
+		l.headerAttr(req.Header),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			attrs = append(attrs, slog.String("body", "<not replayable>"))
+		} else if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			attrs = append(attrs, l.bodyAttrs(data)...)
+		}
+	}
+	l.logger.LogAttrs(req.Context(), slog.LevelDebug, "msgraph request", attrs...)
+}
+
+// logResponse logs the response of the given http.Request with its already read body
+func (l *httpLogger) logResponse(req *http.Request, resp *http.Response, body []byte, duration time.Duration) {
+	if !l.enabled(req) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", redact.URL(req.URL)),
+		slog.Int("status", resp.StatusCode),
+		slog.Duration("duration", duration),
+		l.headerAttr(resp.Header),
+	}
+	attrs = append(attrs, l.bodyAttrs(body)...)
+	l.logger.LogAttrs(req.Context(), slog.LevelDebug, "msgraph response", attrs...)
+}
+
+// logError logs the error of the given http.Request if no response has been received
+func (l *httpLogger) logError(req *http.Request, duration time.Duration, err error) {
+	if !l.enabled(req) {
+		return
+	}
+	l.logger.LogAttrs(req.Context(), slog.LevelDebug, "msgraph request failed",
+		slog.String("method", req.Method),
+		slog.String("url", redact.URL(req.URL)),
+		slog.Duration("duration", duration),
+		slog.String("error", err.Error()))
+}
+
+// headerAttr returns the given headers as group with the redacted headers replaced
+func (l *httpLogger) headerAttr(header http.Header) slog.Attr {
+	attrs := make([]any, 0, len(header))
+	for key, values := range header {
+		attrs = append(attrs, slog.String(key, l.redactor.Header(key, strings.Join(values, ", "))))
+	}
+	return slog.Group("header", attrs...)
+}
+
+// bodyAttrs returns the redacted body, truncated to the maximum body size, and its size if truncated
+func (l *httpLogger) bodyAttrs(body []byte) []slog.Attr {
+	if l.maxBodySize == 0 || len(body) == 0 {
+		return nil
+	}
+	redacted := l.redactor.Body(body)
+	if l.maxBodySize > 0 && len(redacted) > l.maxBodySize {
+		return []slog.Attr{slog.String("body", truncate(redacted, l.maxBodySize)+"..."), slog.Int("body_size", len(body))}
+	}
+	return []slog.Attr{slog.String("body", redacted)}
+}
+
+// truncate returns at most the first size bytes of the given string without splitting a UTF-8 encoded rune
+func truncate(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	for size > 0 && !utf8.RuneStart(s[size]) {
+		size--
+	}
+	return s[:size]
+}
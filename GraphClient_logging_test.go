@@ -0,0 +1,163 @@
+package msgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/SerenityITS-Development/go-msgraph/internal/redact"
+)
+
+func TestGraphClient_logger(t *testing.T) {
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"1","userPrincipalName":"alice@contoso.com","employeeId":"`+strings.Repeat("x", 300)+`"}`)
+	})
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "client-secret-value", srv.URL, srv.URL,
+		ClientWithLogger(logger, LogWithRedactedJSONProperties("mobilePhone"), LogWithRedactedHeaders("X-Custom"),
+			LogWithMaxBodySize(200)))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+	_, err = g.CreateUser(User{DisplayName: "Alice", MobilePhone: "+1 555 0100",
+		PasswordProfile: PasswordProfile{Password: "user-password-value"}}, CreateWithHeader("X-Custom", "custom-value"))
+	if err != nil {
+		t.Fatalf("GraphClient.CreateUser() error = %v", err)
+	}
+
+	for _, secret := range []string{"client-secret-value", "test-token", "user-password-value", "+1 555 0100", "custom-value"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("logs contain %q: %v", secret, logs.String())
+		}
+	}
+
+	var records []map[string]interface{}
+	decoder := json.NewDecoder(&logs)
+	for decoder.More() {
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("cannot decode log record: %v", err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 4 {
+		t.Fatalf("logged %d records, want request and response of the token request and the API-call: %v", len(records), records)
+	}
+	for _, record := range records {
+		if record["level"] != "DEBUG" {
+			t.Errorf("record %v, want level DEBUG", record)
+		}
+	}
+
+	tokenRequest := records[0]
+	if body, _ := tokenRequest["body"].(string); !strings.Contains(body, "client_secret=REDACTED") {
+		t.Errorf("token request body = %v, want client_secret redacted", tokenRequest["body"])
+	}
+	if body, _ := records[1]["body"].(string); !strings.Contains(body, `"access_token":"REDACTED"`) {
+		t.Errorf("token response body = %v, want access_token redacted", records[1]["body"])
+	}
+
+	apiRequest := records[2]
+	if header, _ := apiRequest["header"].(map[string]interface{}); header["Authorization"] != "REDACTED" || header["X-Custom"] != "REDACTED" {
+		t.Errorf("API request header = %v, want Authorization and X-Custom redacted", apiRequest["header"])
+	}
+	if body, _ := apiRequest["body"].(string); !strings.Contains(body, `"passwordProfile":{"password":"REDACTED"}`) ||
+		!strings.Contains(body, `"mobilePhone":"REDACTED"`) || !strings.Contains(body, `"displayName":"Alice"`) {
+		t.Errorf("API request body = %v, want password and mobilePhone redacted", apiRequest["body"])
+	}
+
+	apiResponse := records[3]
+	if apiResponse["status"] != float64(200) || apiResponse["body_size"] == nil {
+		t.Errorf("API response = %v, want status and body_size of the truncated body", apiResponse)
+	}
+	if body, _ := apiResponse["body"].(string); len(body) != 203 || !strings.HasSuffix(body, "...") {
+		t.Errorf("API response body = %v, want truncated to 200 bytes", apiResponse["body"])
+	}
+}
+
+func TestGraphClient_loggerDisabled(t *testing.T) {
+	srv := newTestServer(t, nil, nil)
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	if _, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL, ClientWithLogger(logger)); err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("logs at level INFO = %v, want nothing logged", logs.String())
+	}
+}
+
+func TestGraphClient_loggerMalformedTokenResponse(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"token_type":"Bearer","access_token":"leaked-token","expires_in":`) // truncated
+	}, nil)
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	if _, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL, ClientWithLogger(logger)); err == nil {
+		t.Errorf("NewGraphClientWithCustomEndpoint() with malformed token response error = nil, want an error")
+	}
+	if strings.Contains(logs.String(), "leaked-token") || !strings.Contains(logs.String(), redact.UnparseableJSON) {
+		t.Errorf("logs = %v, want the malformed token response redacted", logs.String())
+	}
+}
+
+func TestGraphClient_loggerRedactsQuery(t *testing.T) {
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value":[]}`)
+	})
+	var logs, middlewareLogs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL, ClientWithLogger(logger),
+		ClientWithMiddleware(LoggingMiddleware(log.New(&middlewareLogs, "", 0))))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+	if _, err := g.ListUsers(ListWithFilter("mail eq 'secret@contoso.com'")); err != nil {
+		t.Fatalf("GraphClient.ListUsers() error = %v", err)
+	}
+	for _, l := range []*bytes.Buffer{&logs, &middlewareLogs} {
+		if !strings.Contains(l.String(), srv.URL+"/v1.0/users") || strings.Contains(l.String(), "secret@contoso.com") {
+			t.Errorf("logs = %v, want the URL without query", l.String())
+		}
+	}
+}
+
+func TestHTTPLogger_bodyAttrs(t *testing.T) {
+	l := newHTTPLogger(slog.Default(), []LoggerOption{LogWithMaxBodySize(-1)})
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "JSON", body: `{"access_token":"secret"}`, want: `{"access_token":"REDACTED"}`},
+		{name: "JSON array", body: ` [{"refresh_token":"secret"}]`, want: `[{"refresh_token":"REDACTED"}]`},
+		{name: "Malformed JSON", body: `{"access_token":"secret",`, want: redact.UnparseableJSON},
+		{name: "Form", body: "client_secret=secret&grant_type=client_credentials", want: "client_secret=REDACTED&grant_type=client_credentials"},
+		{name: "Form without redacted fields", body: "grant_type=client_credentials&scope=a+b", want: "grant_type=client_credentials&scope=a+b"},
+		{name: "Text", body: "access_token: secret", want: "access_token: secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Hint: the Content-Type is not considered, the body is sniffed
+			if got := l.bodyAttrs([]byte(tt.body))[0].Value.String(); got != tt.want {
+				t.Errorf("httpLogger.bodyAttrs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// truncated on a rune boundary: each ö is 2 bytes long
+	l.maxBodySize = 5
+	attrs := l.bodyAttrs([]byte("ööööö"))
+	if got := attrs[0].Value.String(); got != "öö..." || attrs[1].Value.Int64() != 10 {
+		t.Errorf("httpLogger.bodyAttrs() = %v, want öö... and body_size 10", attrs)
+	}
+}
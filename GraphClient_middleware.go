@@ -0,0 +1,174 @@
+package msgraph
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/SerenityITS-Development/go-msgraph/internal/redact"
+)
+
+// Handler performs a http.Request of a GraphClient and returns its response. The innermost Handler injects
+// the token, retries according to the RetryPolicy and sends the request with the transport of the GraphClient.
+//
+// The body of a returned response is fully read and may be read again by the caller. For a StatusCode that
+// is not 2xx both the response and a *GraphError are returned.
+type Handler func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps a Handler, e.g. to modify the http.Request before calling next or to inspect the
+// response afterwards. See ClientWithMiddleware.
+//
+//	func(next msgraph.Handler) msgraph.Handler {
+//		return func(req *http.Request) (*http.Response, error) {
+//			req.Header.Set("X-Tenant", "contoso")
+//			return next(req)
+//		}
+//	}
+type Middleware func(next Handler) Handler
+
+// authorizeRequestKey marks the context of a http.Request to the ms graph API, hence the innermost Handler
+// sets its Authorization header. Token requests are not marked.
+type authorizeRequestKey struct{}
+
+// withAuthorization returns a context that marks a http.Request as API-call, see authorizeRequestKey
+func withAuthorization(ctx context.Context) context.Context {
+	return context.WithValue(ctx, authorizeRequestKey{}, true)
+}
+
+// authorizeRequest sets the Authorization header of the given http.Request if it's an API-call. The token
+// is refreshed before if needed.
+func (g *GraphClient) authorizeRequest(req *http.Request) error {
+	if authorize, _ := req.Context().Value(authorizeRequestKey{}).(bool); !authorize {
+		return nil
+	}
+	token, err := g.getToken(req.Context()) // refreshes the token if needed
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", token.GetAccessToken())
+	return nil
+}
+
+// RequestStats collects the attempts of a request performed by the innermost Handler, e.g. for metrics
+// recorded by a Middleware. See WithRequestStats.
+type RequestStats struct {
+	Attempts  int // the number of attempts, hence 1 plus the number of retries
+	Throttled int // the number of throttled (429) responses, including retried ones
+}
+
+// requestStatsKey is the context key of the *RequestStats of a http.Request
+type requestStatsKey struct{}
+
+// WithRequestStats returns a shallow copy of the given http.Request whose attempts are collected in stats
+// by the innermost Handler. Use it in a Middleware before calling the next Handler:
+//
+//	var stats msgraph.RequestStats
+//	resp, err := next(msgraph.WithRequestStats(req, &stats))
+//	retries.Observe(float64(stats.Attempts - 1))
+func WithRequestStats(req *http.Request, stats *RequestStats) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), requestStatsKey{}, stats))
+}
+
+// requestStats returns the *RequestStats of the given http.Request, nil if none have been set
+func requestStats(req *http.Request) *RequestStats {
+	stats, _ := req.Context().Value(requestStatsKey{}).(*RequestStats)
+	return stats
+}
+
+// tokenRequestContext returns the context for the token requests of a TokenProvider called while performing
+// an API-call with the given context. The token requests are neither authorized themselves nor counted in
+// the RequestStats of the API-call.
+func tokenRequestContext(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, authorizeRequestKey{}, false)
+	return context.WithValue(ctx, requestStatsKey{}, (*RequestStats)(nil))
+}
+
+// handler returns the Handler performing all requests of the GraphClient, hence the retrying Handler
+// wrapped by all middlewares. The first Middleware is the outermost one.
+func (g *GraphClient) handler() Handler {
+	h := Handler(g.retryRequest)
+	for i := len(g.middlewares) - 1; i >= 0; i-- {
+		h = g.middlewares[i](h)
+	}
+	return h
+}
+
+// UserAgentMiddleware sets the User-Agent header of all requests to the given value
+func UserAgentMiddleware(userAgent string) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("User-Agent", userAgent)
+			return next(req)
+		}
+	}
+}
+
+// ClientRequestIDMiddleware sets the client-request-id header of all requests without one to a random
+// UUID. As the middlewares wrap the retries, all attempts of a request share the same id. The ms graph API
+// returns it in the response, so it correlates the logs of the application with the ones of Microsoft.
+//
+// See https://docs.microsoft.com/en-us/graph/best-practices-concept#reliability-and-support
+func ClientRequestIDMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("client-request-id") == "" {
+				id, err := newUUID()
+				if err != nil {
+					return nil, fmt.Errorf("cannot generate client-request-id: %w", err)
+				}
+				req.Header.Set("client-request-id", id)
+			}
+			return next(req)
+		}
+	}
+}
+
+// newUUID returns a random UUID version 4
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // variant RFC 4122
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// TimingMiddleware calls the given func after every request with its duration, including all retries. The
+// response is nil if no response has been received, err is the error of the request, e.g. a *GraphError.
+func TimingMiddleware(observe func(req *http.Request, resp *http.Response, duration time.Duration, err error)) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			observe(req, resp, time.Since(start), err)
+			return resp, err
+		}
+	}
+}
+
+// LoggingMiddleware logs the method, URL, StatusCode and duration of every request to the given
+// *log.Logger. Nothing is logged if logger is nil, pass log.Default() to log to the standard logger.
+// Neither headers nor bodies are logged, as they contain tokens and secrets.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	if logger == nil {
+		return func(next Handler) Handler {
+			return next
+		}
+	}
+	logf := logger.Printf
+	return TimingMiddleware(func(req *http.Request, resp *http.Response, duration time.Duration, err error) {
+		url := redact.URL(req.URL)
+		switch {
+		case resp != nil && err != nil:
+			logf("msgraph: %v %v: %v in %v: %v", req.Method, url, resp.StatusCode, duration, err)
+		case resp != nil:
+			logf("msgraph: %v %v: %v in %v", req.Method, url, resp.StatusCode, duration)
+		default:
+			logf("msgraph: %v %v: failed in %v: %v", req.Method, url, duration, err)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package msgraph
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGraphClient_middleware(t *testing.T) {
+	var calls int32
+	var lock sync.Mutex
+	var clientRequestIDs []string
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		clientRequestIDs = append(clientRequestIDs, r.Header.Get("client-request-id"))
+		lock.Unlock()
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Authorization header = %v, want %v", r.Header.Get("Authorization"), "Bearer test-token")
+		}
+		if r.Header.Get("User-Agent") != "test-agent/1.0" {
+			t.Errorf("User-Agent header = %v, want test-agent/1.0", r.Header.Get("User-Agent"))
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"id":"1","userPrincipalName":"alice@contoso.com"}`)
+	})
+
+	var order []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(req *http.Request) (*http.Response, error) {
+				if strings.Contains(req.URL.Path, "/oauth2/") {
+					return next(req) // token requests pass the middlewares as well
+				}
+				order = append(order, name+" before")
+				resp, err := next(req)
+				order = append(order, name+" after")
+				return resp, err
+			}
+		}
+	}
+	var timings int32
+	timing := TimingMiddleware(func(req *http.Request, resp *http.Response, duration time.Duration, err error) {
+		atomic.AddInt32(&timings, 1)
+		if resp == nil || err != nil {
+			t.Errorf("TimingMiddleware() got resp %v and err %v, want the response", resp, err)
+		}
+	})
+	var stats RequestStats
+	collect := func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			if strings.Contains(req.URL.Path, "/oauth2/") {
+				return next(req)
+			}
+			return next(WithRequestStats(req, &stats))
+		}
+	}
+	var logs bytes.Buffer
+	opts := []GraphClientOption{
+		ClientWithRetryPolicy(RetryPolicy{MaxRetries: 1}),
+		ClientWithMiddleware(record("outer"), record("inner")),
+		ClientWithMiddleware(UserAgentMiddleware("test-agent/1.0"), ClientRequestIDMiddleware(), timing,
+			LoggingMiddleware(log.New(&logs, "", 0)), collect),
+	}
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL, opts...)
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+	atomic.StoreInt32(&timings, 0) // Hint: the token request is timed as well
+
+	user, err := g.GetUser("alice@contoso.com")
+	if err != nil {
+		t.Fatalf("GraphClient.GetUser() error = %v", err)
+	}
+	if user.ID != "1" {
+		t.Errorf("GraphClient.GetUser() = %v, want user 1", user)
+	}
+	if want := "[outer before inner before inner after outer after]"; fmt.Sprint(order) != want {
+		t.Errorf("middleware order = %v, want %v", order, want)
+	}
+	if got := atomic.LoadInt32(&timings); got != 1 {
+		t.Errorf("TimingMiddleware() calls = %v, want 1, the middlewares must wrap the retries", got)
+	}
+	if stats != (RequestStats{Attempts: 2, Throttled: 1}) {
+		t.Errorf("RequestStats = %+v, want 2 attempts and 1 throttled response", stats)
+	}
+	if len(clientRequestIDs) != 2 || clientRequestIDs[0] != clientRequestIDs[1] {
+		t.Errorf("client-request-ids = %v, want the same id for both attempts", clientRequestIDs)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(clientRequestIDs[0]) {
+		t.Errorf("client-request-id = %v, want a UUID version 4", clientRequestIDs[0])
+	}
+	if want := "msgraph: GET " + srv.URL + "/v1.0/users/alice@contoso.com: 200 in "; !strings.Contains(logs.String(), want) {
+		t.Errorf("LoggingMiddleware() logged %q, want %q", logs.String(), want)
+	}
+	if strings.Contains(logs.String(), "test-token") {
+		t.Errorf("LoggingMiddleware() logged the token: %q", logs.String())
+	}
+}
+
+func TestGraphClient_middlewareShortCircuit(t *testing.T) {
+	rt := &countingRoundTripper{next: http.DefaultTransport}
+	srv := newTestServer(t, nil, nil)
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL, ClientWithRoundTripper(rt))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+
+	g.applyOptions([]GraphClientOption{ClientWithMiddleware(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Authorization") != "" {
+				t.Errorf("Authorization header is set before the middlewares, want it set by the innermost Handler")
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"id":"2"}`)),
+				Request:    req,
+			}, nil
+		}
+	})})
+	requests := atomic.LoadInt32(&rt.count)
+	user, err := g.GetUser("bob")
+	if err != nil || user.ID != "2" {
+		t.Errorf("GraphClient.GetUser() = %v, %v, want the user of the middleware", user, err)
+	}
+	if got := atomic.LoadInt32(&rt.count); got != requests {
+		t.Errorf("requests sent = %v, want none as the middleware did not call next", got-requests)
+	}
+
+	g.middlewares = nil
+	g.applyOptions([]GraphClientOption{ClientWithMiddleware(func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("denied")
+		}
+	})})
+	if _, err := g.GetUser("bob"); err == nil || err.Error() != "denied" {
+		t.Errorf("GraphClient.GetUser() error = %v, want the error of the middleware", err)
+	}
+}
+
+func TestLoggingMiddleware_nilLogger(t *testing.T) {
+	var logs bytes.Buffer
+	defer func(w io.Writer) { log.SetOutput(w) }(log.Writer())
+	log.SetOutput(&logs)
+
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1"}`)
+	})
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL,
+		ClientWithMiddleware(LoggingMiddleware(nil)))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+	if _, err := g.GetUser("1"); err != nil {
+		t.Errorf("GraphClient.GetUser() error = %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("LoggingMiddleware(nil) logged %q to the standard logger, want nothing", logs.String())
+	}
+}
@@ -0,0 +1,147 @@
+package msgraph
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// GraphClientOption configures a GraphClient upon creation, e.g. with NewGraphClient
+type GraphClientOption func(g *GraphClient)
+
+var (
+	// ClientWithHTTPClient - use the given *http.Client for token acquisition and all API-calls
+	// instead of a default client. This allows to configure proxies, custom TLS roots, connection
+	// pooling or a test transport. The client's Timeout is used as-is.
+	ClientWithHTTPClient = func(httpClient *http.Client) GraphClientOption {
+		return func(g *GraphClient) {
+			g.httpClient = httpClient
+		}
+	}
+
+	// ClientWithRoundTripper - use the given http.RoundTripper as transport for token acquisition
+	// and all API-calls. The http.Client wrapping it uses HttpRequestTimeout as timeout.
+	ClientWithRoundTripper = func(transport http.RoundTripper) GraphClientOption {
+		return func(g *GraphClient) {
+			g.httpClient = &http.Client{
+				Transport: transport,
+				Timeout:   HttpRequestTimeout,
+			}
+		}
+	}
+
+	// ClientWithEndpoints - use the given Azure AD authentication endpoint and ms graph service root endpoint, e.g.
+	// AzureADAuthEndpointUSGov and ServiceRootEndpointUSGovL4 for a national cloud, instead of the global ones.
+	ClientWithEndpoints = func(azureADAuthEndpoint, serviceRootEndpoint string) GraphClientOption {
+		return func(g *GraphClient) {
+			g.azureADAuthEndpoint = azureADAuthEndpoint
+			g.serviceRootEndpoint = serviceRootEndpoint
+		}
+	}
+
+	// ClientWithTokenEndpointVersion - request tokens from the given version of the Azure AD token endpoint. By
+	// default TokenEndpointV2 is used, TokenEndpointV1 is only required for older tenants or applications.
+	ClientWithTokenEndpointVersion = func(version TokenEndpointVersion) GraphClientOption {
+		return func(g *GraphClient) {
+			g.tokenEndpointVersion = version
+		}
+	}
+
+	// ClientWithScopes - request tokens for the given scopes from TokenEndpointV2, e.g. "User.Read" and
+	// "offline_access" for delegated permissions. By default the service root endpoint with /.default is
+	// requested, hence all permissions granted to the application.
+	ClientWithScopes = func(scopes ...string) GraphClientOption {
+		return func(g *GraphClient) {
+			g.scopes = scopes
+		}
+	}
+
+	// ClientWithTokenCache - load Tokens from and store them in the given TokenCache, e.g. a FileTokenCache,
+	// hence GraphClients in other processes reuse the Token instead of requesting a new one.
+	ClientWithTokenCache = func(cache TokenCache) GraphClientOption {
+		return func(g *GraphClient) {
+			g.tokenCache = cache
+		}
+	}
+
+	// ClientWithAPIVersion - use the given API version, e.g. APIVersionBeta, for all API-calls instead of APIVersion.
+	// The version of a single API-call can be overridden with its query options, e.g. GetWithAPIVersion.
+	ClientWithAPIVersion = func(version string) GraphClientOption {
+		return func(g *GraphClient) {
+			g.apiVersion = version
+		}
+	}
+
+	// ClientWithLazyInit - do not grab a token when creating the GraphClient, the first API-call grabs it
+	// instead. Hence creating a GraphClient neither requires connectivity nor fails on invalid credentials,
+	// these errors are returned by the first API-call.
+	ClientWithLazyInit = func() GraphClientOption {
+		return func(g *GraphClient) {
+			g.lazyInit = true
+		}
+	}
+
+	// ClientWithMiddleware - wrap all requests of the GraphClient, including token requests, with the given
+	// middlewares, e.g. UserAgentMiddleware or LoggingMiddleware. The middlewares wrap the token injection,
+	// the retries and the transport, hence they see every request once, regardless of its retries. The
+	// first Middleware is the outermost one, further calls append middlewares.
+	ClientWithMiddleware = func(middlewares ...Middleware) GraphClientOption {
+		return func(g *GraphClient) {
+			g.middlewares = append(g.middlewares, middlewares...)
+		}
+	}
+
+	// ClientWithLogger - log every request sent and every response received, including token requests and
+	// retries, to the given *slog.Logger at debug level. Credentials are redacted: the Authorization header,
+	// secrets and tokens of the token endpoint and passwordProfile.password. URLs are logged without their query
+	// and malformed JSON bodies are not logged at all. Further redactions and the maximum size of logged bodies
+	// are configured with the given LoggerOptions, e.g. LogWithRedactedJSONProperties. A nil logger disables
+	// logging.
+	ClientWithLogger = func(logger *slog.Logger, opts ...LoggerOption) GraphClientOption {
+		return func(g *GraphClient) {
+			g.logger = nil
+			if logger != nil {
+				g.logger = newHTTPLogger(logger, opts)
+			}
+		}
+	}
+
+	// ClientWithRetryPolicy - retry throttled and temporarily failed API-calls according to the
+	// given RetryPolicy, e.g. DefaultRetryPolicy. By default no API-call is retried.
+	ClientWithRetryPolicy = func(policy RetryPolicy) GraphClientOption {
+		return func(g *GraphClient) {
+			g.retryPolicy = policy
+		}
+	}
+
+	// ClientWithMaxConcurrency - limit the number of parallel in-flight requests of the GraphClient
+	// to maxConcurrency. Requests exceeding the limit wait until a request finished or their context
+	// is done. By default the number of parallel requests is unlimited.
+	ClientWithMaxConcurrency = func(maxConcurrency int) GraphClientOption {
+		return func(g *GraphClient) {
+			if maxConcurrency > 0 {
+				g.concurrencyLimit = make(chan struct{}, maxConcurrency)
+			} else {
+				g.concurrencyLimit = nil
+			}
+		}
+	}
+)
+
+// applyOptions applies all given GraphClientOption to the GraphClient
+func (g *GraphClient) applyOptions(opts []GraphClientOption) {
+	for idx := range opts {
+		opts[idx](g)
+	}
+}
+
+// getHTTPClient returns the *http.Client to be used for all requests of this GraphClient. If no
+// client has been configured via ClientWithHTTPClient or ClientWithRoundTripper, a default
+// client with HttpRequestTimeout is returned.
+func (g *GraphClient) getHTTPClient() *http.Client {
+	if g.httpClient != nil {
+		return g.httpClient
+	}
+	return &http.Client{
+		Timeout: HttpRequestTimeout,
+	}
+}
@@ -0,0 +1,105 @@
+package msgraph
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGraphClientOptions_HTTPClient(t *testing.T) {
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Authorization header = %v, want %v", r.Header.Get("Authorization"), "Bearer test-token")
+		}
+		fmt.Fprint(w, `{"id":"1","userPrincipalName":"alice@contoso.com"}`)
+	})
+
+	tests := []struct {
+		name string
+		opt  func(rt http.RoundTripper) GraphClientOption
+	}{
+		{
+			name: "ClientWithHTTPClient",
+			opt: func(rt http.RoundTripper) GraphClientOption {
+				return ClientWithHTTPClient(&http.Client{Transport: rt})
+			},
+		}, {
+			name: "ClientWithRoundTripper",
+			opt:  ClientWithRoundTripper,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &countingRoundTripper{next: http.DefaultTransport}
+			g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL, tt.opt(rt))
+			if err != nil {
+				t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+			}
+			user, err := g.GetUser("alice@contoso.com")
+			if err != nil {
+				t.Fatalf("GraphClient.GetUser() error = %v", err)
+			}
+			if user.UserPrincipalName != "alice@contoso.com" {
+				t.Errorf("GraphClient.GetUser() = %v, want alice@contoso.com", user.UserPrincipalName)
+			}
+			if got := atomic.LoadInt32(&rt.count); got != 2 {
+				t.Errorf("RoundTripper was used for %d requests, want 2 (token + API-call)", got)
+			}
+		})
+	}
+}
+
+func TestGraphClientOptions_APIVersion(t *testing.T) {
+	var lock sync.Mutex
+	var paths []string
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		lock.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			// Hint: a beta response containing properties unknown to the typed model
+			fmt.Fprint(w, `{"id":"1","userPrincipalName":"alice@contoso.com","employeeHireDate":null,"customSecurityAttributes":{"a":{"b":1}},
+				"signInActivity":{"lastSignInDateTime":"2021-03-01T10:00:00Z","lastSuccessfulSignInDateTime":"2021-03-01T09:00:00Z"}}`)
+		case http.MethodPost:
+			fmt.Fprint(w, `{"id":"2"}`)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL, ClientWithAPIVersion(APIVersionBeta))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+	user, err := g.GetUser("1")
+	if err != nil {
+		t.Fatalf("GraphClient.GetUser() error = %v", err)
+	}
+	if user.SignInActivity == nil || user.SignInActivity.LastSuccessfulSignInDateTime == nil {
+		t.Errorf("GraphClient.GetUser() SignInActivity = %+v, want LastSuccessfulSignInDateTime", user.SignInActivity)
+	}
+	if _, err := g.GetUser("1", GetWithAPIVersion(APIVersion)); err != nil {
+		t.Errorf("GraphClient.GetUser() error = %v", err)
+	}
+	if _, err := g.ListUsers(ListWithAPIVersion(APIVersion)); err != nil {
+		t.Errorf("GraphClient.ListUsers() error = %v", err)
+	}
+	if _, err := g.CreateUser(User{DisplayName: "Bob"}, CreateWithAPIVersion(APIVersion)); err != nil {
+		t.Errorf("GraphClient.CreateUser() error = %v", err)
+	}
+	if err := user.UpdateUser(User{DisplayName: "Alice"}, UpdateWithAPIVersion(APIVersion)); err != nil {
+		t.Errorf("User.UpdateUser() error = %v", err)
+	}
+	if err := user.DeleteUser(DeleteWithAPIVersion(APIVersion)); err != nil {
+		t.Errorf("User.DeleteUser() error = %v", err)
+	}
+
+	want := []string{"GET /beta/users/1", "GET /v1.0/users/1", "GET /v1.0/users", "POST /v1.0/users", "PATCH /v1.0/users/1", "DELETE /v1.0/users/1"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("requests = %v, want %v", paths, want)
+	}
+}
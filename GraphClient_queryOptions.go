@@ -4,12 +4,14 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"strconv"
 )
 
 type getRequestParams interface {
 	Context() context.Context
 	Values() url.Values
 	Headers() http.Header
+	APIVersion() string
 }
 
 type GetQueryOption func(opts *getQueryOptions)
@@ -18,8 +20,12 @@ type ListQueryOption func(opts *listQueryOptions)
 
 type CreateQueryOption func(opts *createQueryOptions)
 
+// UpdateQueryOption configures an update API-call. There are no $select and $expand options, as the update
+// functions only return an error and the response of the PATCH request is not parsed.
 type UpdateQueryOption func(opts *updateQueryOptions)
 
+// DeleteQueryOption configures a delete API-call. There are no $select and $expand options, as the DELETE request
+// does not return the resource.
 type DeleteQueryOption func(opts *deleteQueryOptions)
 
 var (
@@ -37,6 +43,34 @@ var (
 		}
 	}
 
+	// GetWithExpand - $expand - Includes related resources, e.g. "manager" - https://docs.microsoft.com/en-us/graph/query-parameters#expand-parameter
+	GetWithExpand = func(expandParam string) GetQueryOption {
+		return func(opts *getQueryOptions) {
+			opts.queryValues.Add(odataExpandParamKey, expandParam)
+		}
+	}
+
+	// GetWithFormat - $format - Returns the result in the given media format, e.g. "json" - https://docs.microsoft.com/en-us/graph/query-parameters#format-parameter
+	GetWithFormat = func(format string) GetQueryOption {
+		return func(opts *getQueryOptions) {
+			opts.queryValues.Set(odataFormatParamKey, format)
+		}
+	}
+
+	// GetWithHeader - sets the given HTTP header of the request, e.g. Prefer or ConsistencyLevel
+	GetWithHeader = func(key, value string) GetQueryOption {
+		return func(opts *getQueryOptions) {
+			opts.queryHeaders.Set(key, value)
+		}
+	}
+
+	// GetWithAPIVersion - use the given API version, e.g. APIVersionBeta, for this API-call instead of the one of the GraphClient
+	GetWithAPIVersion = func(version string) GetQueryOption {
+		return func(opts *getQueryOptions) {
+			opts.apiVersion = version
+		}
+	}
+
 	// ListWithContext - add a context.Context to the HTTP request e.g. to allow cancellation
 	ListWithContext = func(ctx context.Context) ListQueryOption {
 		return func(opts *listQueryOptions) {
@@ -61,11 +95,103 @@ var (
 	// ListWithSearch - $search - Returns results based on search criteria - https://docs.microsoft.com/en-us/graph/query-parameters#search-parameter
 	ListWithSearch = func(searchParam string) ListQueryOption {
 		return func(opts *listQueryOptions) {
-			opts.queryHeaders.Add("ConsistencyLevel", "eventual")
+			opts.queryHeaders.Set("ConsistencyLevel", "eventual")
 			opts.queryValues.Add(odataSearchParamKey, searchParam)
 		}
 	}
 
+	// ListWithOrderBy - $orderby - Sorts the results, e.g. "displayName desc". Combined with ListWithFilter this is
+	// an advanced query for directory objects, add ListWithCount in that case - https://docs.microsoft.com/en-us/graph/query-parameters#orderby-parameter
+	ListWithOrderBy = func(orderByParam string) ListQueryOption {
+		return func(opts *listQueryOptions) {
+			opts.queryValues.Add(odataOrderByParamKey, orderByParam)
+		}
+	}
+
+	// ListWithExpand - $expand - Includes related resources, e.g. "manager" - https://docs.microsoft.com/en-us/graph/query-parameters#expand-parameter
+	ListWithExpand = func(expandParam string) ListQueryOption {
+		return func(opts *listQueryOptions) {
+			opts.queryValues.Add(odataExpandParamKey, expandParam)
+		}
+	}
+
+	// ListWithTop - $top - Returns at most top results in total, no further pages are loaded once reached. Use
+	// ListWithPageSize to set the size of the pages only. The ms graph API returns at most MaxPageSize results per
+	// page, hence a top above MaxPageSize is not sent as $top but loads pages of MaxPageSize results until top
+	// results are reached - https://docs.microsoft.com/en-us/graph/query-parameters#top-parameter
+	ListWithTop = func(top int) ListQueryOption {
+		return func(opts *listQueryOptions) {
+			opts.top = top
+			if top > 0 && top <= MaxPageSize {
+				opts.queryValues.Set(odataTopParamKey, strconv.Itoa(top))
+			}
+		}
+	}
+
+	// ListWithSkip - $skip - Skips the given number of results, not supported by every resource - https://docs.microsoft.com/en-us/graph/query-parameters#skip-parameter
+	ListWithSkip = func(skip int) ListQueryOption {
+		return func(opts *listQueryOptions) {
+			opts.queryValues.Set(odataSkipParamKey, strconv.Itoa(skip))
+		}
+	}
+
+	// ListWithCount - $count - Requests the total number of matching results, which is stored in count once the
+	// API-call returned. Sets the header ConsistencyLevel: eventual, hence advanced queries on directory objects
+	// are possible as well - https://docs.microsoft.com/en-us/graph/query-parameters#count-parameter
+	ListWithCount = func(count *int) ListQueryOption {
+		return func(opts *listQueryOptions) {
+			opts.queryHeaders.Set("ConsistencyLevel", "eventual")
+			opts.queryValues.Set(odataCountParamKey, "true")
+			opts.count = count
+		}
+	}
+
+	// ListWithFormat - $format - Returns the results in the given media format, e.g. "json" - https://docs.microsoft.com/en-us/graph/query-parameters#format-parameter
+	ListWithFormat = func(format string) ListQueryOption {
+		return func(opts *listQueryOptions) {
+			opts.queryValues.Set(odataFormatParamKey, format)
+		}
+	}
+
+	// ListWithHeader - sets the given HTTP header of the requests of all pages, e.g. Prefer or ConsistencyLevel
+	ListWithHeader = func(key, value string) ListQueryOption {
+		return func(opts *listQueryOptions) {
+			opts.queryHeaders.Set(key, value)
+		}
+	}
+
+	// ListWithAPIVersion - use the given API version, e.g. APIVersionBeta, for this API-call instead of the one of the GraphClient
+	ListWithAPIVersion = func(version string) ListQueryOption {
+		return func(opts *listQueryOptions) {
+			opts.apiVersion = version
+		}
+	}
+
+	// ListWithPageSize - $top - Sets the number of items per page (max. MaxPageSize), by default MaxPageSize is used.
+	// All pages are still loaded, except when using an iterator, e.g. GraphClient.IterateUsers - https://docs.microsoft.com/en-us/graph/paging
+	ListWithPageSize = func(pageSize int) ListQueryOption {
+		return func(opts *listQueryOptions) {
+			opts.queryValues.Set(odataTopParamKey, strconv.Itoa(pageSize))
+		}
+	}
+
+	// ListWithNextLink - resume an iterator, e.g. GraphClient.IterateUsers, at the given @odata.nextLink
+	// previously returned by NextLink() of an iterator. All other query options are already part of the nextLink.
+	ListWithNextLink = func(nextLink string) ListQueryOption {
+		return func(opts *listQueryOptions) {
+			opts.nextLink = nextLink
+		}
+	}
+
+	// ListWithDeltaLink - resume a delta query, e.g. GraphClient.ListUsersDelta, at the given @odata.deltaLink previously
+	// returned as DeltaLink. Only changes since that delta query are returned, all other query options are already
+	// part of the deltaLink - https://docs.microsoft.com/en-us/graph/delta-query-overview
+	ListWithDeltaLink = func(deltaLink string) ListQueryOption {
+		return func(opts *listQueryOptions) {
+			opts.deltaLink = deltaLink
+		}
+	}
+
 	// CreateWithContext - add a context.Context to the HTTP request e.g. to allow cancellation
 	CreateWithContext = func(ctx context.Context) CreateQueryOption {
 		return func(opts *createQueryOptions) {
@@ -73,25 +199,104 @@ var (
 		}
 	}
 
+	// CreateWithHeader - sets the given HTTP header of the request, e.g. Prefer
+	CreateWithHeader = func(key, value string) CreateQueryOption {
+		return func(opts *createQueryOptions) {
+			opts.queryHeaders.Set(key, value)
+		}
+	}
+
+	// CreateWithSelect - $select - Filters the properties (columns) of the created resource returned by the API-call -
+	// https://docs.microsoft.com/en-us/graph/query-parameters#select-parameter
+	CreateWithSelect = func(selectParam string) CreateQueryOption {
+		return func(opts *createQueryOptions) {
+			opts.queryValues.Add(odataSelectParamKey, selectParam)
+		}
+	}
+
+	// CreateWithExpand - $expand - Includes related resources in the created resource returned by the API-call -
+	// https://docs.microsoft.com/en-us/graph/query-parameters#expand-parameter
+	CreateWithExpand = func(expandParam string) CreateQueryOption {
+		return func(opts *createQueryOptions) {
+			opts.queryValues.Add(odataExpandParamKey, expandParam)
+		}
+	}
+
+	// CreateWithAPIVersion - use the given API version, e.g. APIVersionBeta, for this API-call instead of the one of the GraphClient
+	CreateWithAPIVersion = func(version string) CreateQueryOption {
+		return func(opts *createQueryOptions) {
+			opts.apiVersion = version
+		}
+	}
+
 	// UpdateWithContext - add a context.Context to the HTTP request e.g. to allow cancellation
 	UpdateWithContext = func(ctx context.Context) UpdateQueryOption {
 		return func(opts *updateQueryOptions) {
 			opts.ctx = ctx
 		}
 	}
+
+	// UpdateWithHeader - sets the given HTTP header of the request, e.g. Prefer: return=representation
+	UpdateWithHeader = func(key, value string) UpdateQueryOption {
+		return func(opts *updateQueryOptions) {
+			opts.queryHeaders.Set(key, value)
+		}
+	}
+
+	// UpdateWithAPIVersion - use the given API version, e.g. APIVersionBeta, for this API-call instead of the one of the GraphClient
+	UpdateWithAPIVersion = func(version string) UpdateQueryOption {
+		return func(opts *updateQueryOptions) {
+			opts.apiVersion = version
+		}
+	}
+
+	// UpdateWithIfMatch - only update the resource if its current @odata.etag is the given one. Otherwise the update
+	// fails with ErrPreconditionFailed, e.g. if the resource has been changed by an Outlook client in the meantime
+	UpdateWithIfMatch = func(etag string) UpdateQueryOption {
+		return func(opts *updateQueryOptions) {
+			opts.queryHeaders.Set("If-Match", etag)
+		}
+	}
+
+	// UpdateWithConcurrencyCheck - only update the resource if it has not been changed since it was read, e.g. by
+	// CalendarEvent.Update and OutlookCategory.Update. The @odata.etag captured at read time is sent
+	// as If-Match header, hence the update fails with ErrPreconditionFailed if the resource has been changed in the
+	// meantime and with ErrNoETag if none has been captured.
+	UpdateWithConcurrencyCheck = func() UpdateQueryOption {
+		return func(opts *updateQueryOptions) {
+			opts.concurrencyCheck = true
+		}
+	}
+
 	// DeleteWithContext - add a context.Context to the HTTP request e.g. to allow cancellation
 	DeleteWithContext = func(ctx context.Context) DeleteQueryOption {
 		return func(opts *deleteQueryOptions) {
 			opts.ctx = ctx
 		}
 	}
+
+	// DeleteWithHeader - sets the given HTTP header of the request
+	DeleteWithHeader = func(key, value string) DeleteQueryOption {
+		return func(opts *deleteQueryOptions) {
+			opts.queryHeaders.Set(key, value)
+		}
+	}
+
+	// DeleteWithAPIVersion - use the given API version, e.g. APIVersionBeta, for this API-call instead of the one of the GraphClient
+	DeleteWithAPIVersion = func(version string) DeleteQueryOption {
+		return func(opts *deleteQueryOptions) {
+			opts.apiVersion = version
+		}
+	}
 )
 
 // getQueryOptions allow to optionally pass OData query options
 // see https://docs.microsoft.com/en-us/graph/query-parameters
 type getQueryOptions struct {
-	ctx         context.Context
-	queryValues url.Values
+	ctx          context.Context
+	queryValues  url.Values
+	queryHeaders http.Header
+	apiVersion   string // overrides the API version of the GraphClient if not empty, see GetWithAPIVersion
 }
 
 func (g *getQueryOptions) Context() context.Context {
@@ -106,12 +311,20 @@ func (g getQueryOptions) Values() url.Values {
 }
 
 func (g getQueryOptions) Headers() http.Header {
-	return http.Header{}
+	if g.queryHeaders == nil {
+		return http.Header{}
+	}
+	return g.queryHeaders
+}
+
+func (g getQueryOptions) APIVersion() string {
+	return g.apiVersion
 }
 
 func compileGetQueryOptions(options []GetQueryOption) *getQueryOptions {
 	var opts = &getQueryOptions{
-		queryValues: url.Values{},
+		queryValues:  url.Values{},
+		queryHeaders: http.Header{},
 	}
 	for idx := range options {
 		options[idx](opts)
@@ -124,7 +337,10 @@ func compileGetQueryOptions(options []GetQueryOption) *getQueryOptions {
 // see https://docs.microsoft.com/en-us/graph/query-parameters
 type listQueryOptions struct {
 	getQueryOptions
-	queryHeaders http.Header
+	nextLink  string // resume an iterator at this nextLink, see ListWithNextLink
+	deltaLink string // resume a delta query at this deltaLink, see ListWithDeltaLink
+	top       int    // the maximum number of results in total if > 0, see ListWithTop
+	count     *int   // receives the @odata.count if not nil, see ListWithCount
 }
 
 func (g *listQueryOptions) Context() context.Context {
@@ -138,16 +354,12 @@ func (g listQueryOptions) Values() url.Values {
 	return g.queryValues
 }
 
-func (g listQueryOptions) Headers() http.Header {
-	return g.queryHeaders
-}
-
 func compileListQueryOptions(options []ListQueryOption) *listQueryOptions {
 	var opts = &listQueryOptions{
 		getQueryOptions: getQueryOptions{
-			queryValues: url.Values{},
+			queryValues:  url.Values{},
+			queryHeaders: http.Header{},
 		},
-		queryHeaders: http.Header{},
 	}
 	for idx := range options {
 		options[idx](opts)
@@ -171,7 +383,8 @@ func (g *createQueryOptions) Context() context.Context {
 func compileCreateQueryOptions(options []CreateQueryOption) *createQueryOptions {
 	var opts = &createQueryOptions{
 		getQueryOptions: getQueryOptions{
-			queryValues: url.Values{},
+			queryValues:  url.Values{},
+			queryHeaders: http.Header{},
 		},
 	}
 	for idx := range options {
@@ -184,6 +397,8 @@ func compileCreateQueryOptions(options []CreateQueryOption) *createQueryOptions
 // updateQueryOptions allows to add a context to the request
 type updateQueryOptions struct {
 	getQueryOptions
+	concurrencyCheck bool   // see UpdateWithConcurrencyCheck
+	etag             string // the etag captured at read time, sent as If-Match if concurrencyCheck is set
 }
 
 func (g *updateQueryOptions) Context() context.Context {
@@ -196,16 +411,27 @@ func (g *updateQueryOptions) Context() context.Context {
 func compileUpdateQueryOptions(options []UpdateQueryOption) *updateQueryOptions {
 	var opts = &updateQueryOptions{
 		getQueryOptions: getQueryOptions{
-			queryValues: url.Values{},
+			queryValues:  url.Values{},
+			queryHeaders: http.Header{},
 		},
 	}
 	for idx := range options {
 		options[idx](opts)
 	}
+	if opts.concurrencyCheck && opts.etag != "" && opts.queryHeaders.Get("If-Match") == "" {
+		opts.queryHeaders.Set("If-Match", opts.etag)
+	}
 
 	return opts
 }
 
+// updateWithETag passes the etag captured at read time of the resource to update, see UpdateWithConcurrencyCheck
+func updateWithETag(etag string) UpdateQueryOption {
+	return func(opts *updateQueryOptions) {
+		opts.etag = etag
+	}
+}
+
 // deleteQueryOptions allows to add a context to the request
 type deleteQueryOptions struct {
 	getQueryOptions
@@ -221,7 +447,8 @@ func (g *deleteQueryOptions) Context() context.Context {
 func compileDeleteQueryOptions(options []DeleteQueryOption) *deleteQueryOptions {
 	var opts = &deleteQueryOptions{
 		getQueryOptions: getQueryOptions{
-			queryValues: url.Values{},
+			queryValues:  url.Values{},
+			queryHeaders: http.Header{},
 		},
 	}
 	for idx := range options {
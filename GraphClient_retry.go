@@ -0,0 +1,218 @@
+package msgraph
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures if and how failed API-calls of a GraphClient are retried. Throttled (429)
+// and temporarily unavailable (503, 504) responses as well as transport errors are retried with a
+// jittered exponential backoff. A Retry-After header sent by the ms graph API is always honoured.
+// No retry is performed if the wait-time would exceed the deadline of the request context.
+//
+// The zero value disables retries. Use ClientWithRetryPolicy to set a RetryPolicy, e.g. DefaultRetryPolicy.
+//
+// See https://docs.microsoft.com/en-us/graph/throttling
+type RetryPolicy struct {
+	MaxRetries         int           // maximum number of retries per request, 0 disables retries
+	MinBackoff         time.Duration // backoff before the first retry, doubled on every further retry
+	MaxBackoff         time.Duration // upper limit of the backoff, does not limit Retry-After
+	RetryStatusCodes   []int         // HTTP StatusCodes that will be retried, defaults to 429, 503 and 504 if empty
+	RetryNonIdempotent bool          // also retry POST and PATCH requests, by default only idempotent methods are retried
+
+	// OnRetry is called, if not nil, before waiting for the next attempt. Parameter attempt starts at 1
+	// for the first retry, err is the error of the previous attempt.
+	OnRetry func(req *http.Request, attempt int, wait time.Duration, err error)
+}
+
+// DefaultRetryPolicy is a reasonable RetryPolicy for most applications: up to 4 retries of idempotent
+// requests, starting with a backoff of one second up to 30 seconds.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 4,
+	MinBackoff: time.Second,
+	MaxBackoff: 30 * time.Second,
+}
+
+// defaultRetryStatusCodes are used if RetryPolicy.RetryStatusCodes is empty
+var defaultRetryStatusCodes = []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// isRetryableMethod returns true if requests with the given http method may be retried
+func (r RetryPolicy) isRetryableMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return r.RetryNonIdempotent
+}
+
+// isRetryableStatusCode returns true if a response with the given StatusCode may be retried
+func (r RetryPolicy) isRetryableStatusCode(statusCode int) bool {
+	codes := r.RetryStatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryStatusCodes
+	}
+	for _, code := range codes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the time to wait before the given attempt (starting at 1). If the response
+// contains a Retry-After header, it's value is used, otherwise a jittered exponential backoff.
+func (r RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait
+		}
+	}
+	wait := r.MinBackoff
+	for i := 1; i < attempt && (r.MaxBackoff <= 0 || wait < r.MaxBackoff); i++ {
+		wait *= 2
+	}
+	if r.MaxBackoff > 0 && wait > r.MaxBackoff {
+		wait = r.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+	// jitter: wait between 50% and 100% of the calculated backoff
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either given in seconds or as HTTP-date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// doRequest performs the given http.Request with the Middleware chain of the GraphClient, see
+// ClientWithMiddleware, and retries it according to the RetryPolicy of the GraphClient. Returns the
+// last response and its already read body. The returned error is a *GraphError if the StatusCode is
+// not 2xx, with GraphError.Retries set to the retries performed.
+func (g *GraphClient) doRequest(req *http.Request) (*http.Response, []byte, error) {
+	resp, err := g.handler()(req)
+	if resp == nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil && err == nil {
+		return nil, nil, fmt.Errorf("HTTP response read error: %w of http.Request: %v", readErr, req.URL)
+	}
+	return resp, body, err
+}
+
+// retryRequest is the innermost Handler of the Middleware chain. It authorizes the given http.Request if it
+// is an API-call, sends it and retries it according to the RetryPolicy of the GraphClient. For a response
+// with a StatusCode that is not 2xx, both the response and a *GraphError are returned.
+func (g *GraphClient) retryRequest(req *http.Request) (*http.Response, error) {
+	policy := g.retryPolicy
+	stats := requestStats(req)
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil { // re-create the body, it has been consumed by the previous attempt
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("cannot reset body of http.Request %v for retry: %w", req.URL, err)
+			}
+			req.Body = body
+		}
+		if err := g.authorizeRequest(req); err != nil { // Hint: on every attempt, the token may have expired while waiting
+			return nil, err
+		}
+
+		var reqErr error
+		resp, body, err := g.sendRequest(req)
+		if stats != nil {
+			stats.Attempts++
+			if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+				stats.Throttled++
+			}
+		}
+		if err != nil {
+			reqErr = err
+			if req.Context().Err() != nil {
+				return nil, reqErr // the context is done, no retry
+			}
+		} else {
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body)) // Hint: already read and closed by sendRequest
+			if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+				return resp, nil
+			}
+			graphErr := newGraphError(resp, body)
+			graphErr.Retries = attempt
+			if !policy.isRetryableStatusCode(resp.StatusCode) {
+				return resp, graphErr
+			}
+			reqErr = graphErr
+		}
+
+		if attempt >= policy.MaxRetries || !policy.isRetryableMethod(req.Method) || (req.Body != nil && req.GetBody == nil) {
+			return resp, reqErr
+		}
+
+		wait := policy.backoff(attempt+1, resp)
+		if deadline, ok := req.Context().Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return resp, reqErr // waiting would exceed the deadline of the request
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(req, attempt+1, wait, reqErr)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return resp, reqErr
+		case <-timer.C:
+		}
+	}
+}
+
+// sendRequest sends the given http.Request once with the http.Client of the GraphClient and reads the
+// whole response body. If a concurrency limit is set with ClientWithMaxConcurrency it waits for a
+// free slot before.
+func (g *GraphClient) sendRequest(req *http.Request) (*http.Response, []byte, error) {
+	if g.concurrencyLimit != nil {
+		select {
+		case g.concurrencyLimit <- struct{}{}:
+			defer func() { <-g.concurrencyLimit }() // free the slot after the body has been read
+		case <-req.Context().Done():
+			return nil, nil, fmt.Errorf("HTTP response error: %w of http.Request: %v", req.Context().Err(), req.URL)
+		}
+	}
+	g.logger.logRequest(req)
+	start := time.Now()
+	resp, err := g.getHTTPClient().Do(req)
+	if err != nil {
+		g.logger.logError(req, time.Since(start), err)
+		return nil, nil, fmt.Errorf("HTTP response error: %w of http.Request: %v", err, req.URL)
+	}
+	defer resp.Body.Close() // close body when func returns
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		g.logger.logError(req, time.Since(start), err)
+		return nil, nil, fmt.Errorf("HTTP response read error: %w of http.Request: %v", err, req.URL)
+	}
+	g.logger.logResponse(req, resp, body, time.Since(start))
+	return resp, body, nil
+}
@@ -0,0 +1,116 @@
+package msgraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{name: "empty", value: "", want: 0, wantOk: false},
+		{name: "seconds", value: "7", want: 7 * time.Second, wantOk: true},
+		{name: "date in the past", value: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, wantOk: true},
+		{name: "invalid", value: "soon", want: 0, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("parseRetryAfter(%v) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		got := policy.backoff(attempt+1, nil)
+		if got < max/2 || got > max {
+			t.Errorf("RetryPolicy.backoff(%d) = %v, want between %v and %v", attempt+1, got, max/2, max)
+		}
+	}
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"42"}}}
+	if got := policy.backoff(1, resp); got != 42*time.Second {
+		t.Errorf("RetryPolicy.backoff() with Retry-After = %v, want %v", got, 42*time.Second)
+	}
+}
+
+func TestGraphClient_retry(t *testing.T) {
+	var calls int32
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"code":"TooManyRequests","message":"throttled"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"1","userPrincipalName":"alice@contoso.com"}`)
+	})
+
+	var retries int32
+	policy := RetryPolicy{
+		MaxRetries: 3,
+		OnRetry: func(req *http.Request, attempt int, wait time.Duration, err error) {
+			atomic.AddInt32(&retries, 1)
+			if !errors.Is(err, ErrThrottled) {
+				t.Errorf("OnRetry() err = %v, want ErrThrottled", err)
+			}
+		},
+	}
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL, ClientWithRetryPolicy(policy))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+
+	t.Run("GET is retried", func(t *testing.T) {
+		user, err := g.GetUser("alice@contoso.com")
+		if err != nil {
+			t.Fatalf("GraphClient.GetUser() error = %v", err)
+		}
+		if user.ID != "1" || atomic.LoadInt32(&retries) != 2 {
+			t.Errorf("GraphClient.GetUser() = %v with %d retries, want ID 1 with 2 retries", user.ID, retries)
+		}
+	})
+
+	t.Run("POST is not retried", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		atomic.StoreInt32(&retries, 0)
+		_, err := g.CreateUser(User{DisplayName: "bob"})
+		var graphErr *GraphError
+		if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusTooManyRequests || graphErr.Retries != 0 {
+			t.Errorf("GraphClient.CreateUser() error = %v, want GraphError 429 without retries", err)
+		}
+	})
+
+	t.Run("deadline stops retries", func(t *testing.T) {
+		unavailable := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		slow := RetryPolicy{MaxRetries: 3, MinBackoff: time.Hour, MaxBackoff: time.Hour}
+		g2, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", unavailable.URL, unavailable.URL, ClientWithRetryPolicy(slow))
+		if err != nil {
+			t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = g2.ListUsers(ListWithContext(ctx))
+		var graphErr *GraphError
+		if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("GraphClient.ListUsers() error = %v, want GraphError 503", err)
+		}
+		if ctx.Err() != nil {
+			t.Errorf("GraphClient.ListUsers() waited until the deadline instead of returning immediately")
+		}
+	})
+}
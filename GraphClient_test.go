@@ -12,6 +12,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/SerenityITS-Development/go-msgraph/msgraphtest"
 )
 
 // get graph client config from environment
@@ -50,7 +52,37 @@ func getEnvOrPanic(key string) string {
 	return val
 }
 
-func TestMain(m *testing.M) {
+// startOfflineTestServer starts a msgraphtest.Server seeded with the objects expected by the tests and sets the
+// test configuration to it, used if MSGraphOffline is set to true
+func startOfflineTestServer() *msgraphtest.Server {
+	srv := msgraphtest.NewServer()
+	user := srv.AddUser(msgraphtest.Object{"displayName": "Felix", "userPrincipalName": "felix@contoso.test", "mail": "felix@contoso.test",
+		"givenName": "Felix", "surname": "Test", "accountEnabled": true, "businessPhones": []string{"+43 1 234567"}})
+	group := srv.AddGroup(msgraphtest.Object{"displayName": "technicians", "mail": "technicians@contoso.test", "securityEnabled": true})
+	srv.AddMember(group["id"].(string), user["id"].(string))
+	calendarGroup := srv.AddCalendarGroup(user["id"].(string), msgraphtest.Object{"name": "My Calendars"})
+	for _, name := range []string{"Calendar", "Birthdays"} {
+		srv.Add("users/"+user["id"].(string)+"/calendarGroups/"+calendarGroup["id"].(string)+"/calendars",
+			msgraphtest.Object{"name": name, "canEdit": true})
+	}
+	start := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Hour)
+	srv.AddEvent(user["id"].(string), "", msgraphtest.Object{"subject": "Team meeting",
+		"start": map[string]string{"dateTime": start.Format("2006-01-02T15:04:05.0000000"), "timeZone": "UTC"},
+		"end":   map[string]string{"dateTime": start.Add(time.Hour).Format("2006-01-02T15:04:05.0000000"), "timeZone": "UTC"}})
+
+	msGraphTenantID, msGraphApplicationID, msGraphClientSecret = "offline-tenant", "offline-application", "offline-secret"
+	msGraphExistingGroupDisplayName = "technicians"
+	msGraphExistingUserPrincipalInGroup = "felix@contoso.test"
+	msGraphExistingCalendarsOfUser = []string{"Calendar", "Birthdays"}
+	msGraphExistingGroupDisplayNameNumRes = 1
+	msGraphDomainNameForCreateTests = "contoso.test"
+	msGraphAzureADAuthEndpoint, msGraphServiceRootEndpoint = srv.URL, srv.URL
+	srv.TenantID, srv.ClientID, srv.ClientSecret = msGraphTenantID, msGraphApplicationID, msGraphClientSecret
+	return srv
+}
+
+// loadTestConfigFromEnv sets the test configuration from the environment variables of a live tenant
+func loadTestConfigFromEnv() {
 	msGraphTenantID = getEnvOrPanic("MSGraphTenantID")
 	msGraphApplicationID = getEnvOrPanic("MSGraphApplicationID")
 	msGraphClientSecret = getEnvOrPanic("MSGraphClientSecret")
@@ -64,17 +96,29 @@ func TestMain(m *testing.M) {
 	if msGraphServiceRootEndpoint = os.Getenv("MSGraphServiceRootEndpoint"); msGraphServiceRootEndpoint == "" {
 		msGraphServiceRootEndpoint = ServiceRootEndpointGlobal
 	}
-	if msGraphExistingCalendarsOfUser = strings.Split(os.Getenv("MSGraphExistingCalendarsOfUser"), ","); msGraphExistingCalendarsOfUser[0] == "" {
-		fmt.Println("Skipping calendar tests due to missing 'MSGraphExistingCalendarsOfUser' value")
-		skipCalendarTests = true
-	}
+	msGraphExistingCalendarsOfUser = strings.Split(os.Getenv("MSGraphExistingCalendarsOfUser"), ",")
 
 	var err error
 	msGraphExistingGroupDisplayNameNumRes, err = strconv.ParseUint(os.Getenv("MSGraphExistingGroupDisplayNameNumRes"), 10, 64)
 	if err != nil {
 		panic(fmt.Sprintf("Environment variable \"MSGraphExistingGroupDisplayNameNumRes\" seems to be invalid, cannot be parsed to unsigned integer: %v", err))
 	}
+}
 
+func TestMain(m *testing.M) {
+	var offline *msgraphtest.Server
+	if useOffline, _ := strconv.ParseBool(os.Getenv("MSGraphOffline")); useOffline {
+		fmt.Println("Testing against msgraphtest.Server due to 'MSGraphOffline' value")
+		offline = startOfflineTestServer()
+	} else {
+		loadTestConfigFromEnv() // Hint: panics if the live tenant is not configured
+	}
+	if msGraphExistingCalendarsOfUser[0] == "" {
+		fmt.Println("Skipping calendar tests due to missing 'MSGraphExistingCalendarsOfUser' value")
+		skipCalendarTests = true
+	}
+
+	var err error
 	graphClient, err = NewGraphClientWithCustomEndpoint(msGraphTenantID, msGraphApplicationID, msGraphClientSecret, msGraphAzureADAuthEndpoint, msGraphServiceRootEndpoint)
 	if err != nil {
 		panic(fmt.Sprintf("Cannot initialize a new GraphClient, error: %v", err))
@@ -82,7 +126,11 @@ func TestMain(m *testing.M) {
 
 	rand.Seed(time.Now().UnixNano())
 
-	os.Exit(m.Run())
+	code := m.Run()
+	if offline != nil {
+		offline.Close()
+	}
+	os.Exit(code)
 }
 
 func randomString(n int) string {
@@ -757,6 +805,14 @@ func TestGetQueryOptions_Values(t *testing.T) {
 				"$select": []string{"displayName,createdDateTime"},
 			}.Encode(),
 		},
+		{
+			name: "add $expand and $format",
+			opts: []GetQueryOption{GetWithExpand("manager"), GetWithFormat("json")},
+			wantValues: url.Values{
+				"$expand": []string{"manager"},
+				"$format": []string{"json"},
+			}.Encode(),
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -823,6 +879,37 @@ func TestListQueryOptions_ValuesAndHeaders(t *testing.T) {
 				"$filter": []string{"displayName eq 'hello world'"},
 			}.Encode(),
 		},
+		{
+			name: "Add $orderby, $expand, $top, $skip and $format",
+			opts: []ListQueryOption{
+				ListWithOrderBy("displayName desc"),
+				ListWithExpand("manager"),
+				ListWithTop(10),
+				ListWithSkip(20),
+				ListWithFormat("json"),
+			},
+			wantValues: url.Values{
+				"$orderby": []string{"displayName desc"},
+				"$expand":  []string{"manager"},
+				"$top":     []string{"10"},
+				"$skip":    []string{"20"},
+				"$format":  []string{"json"},
+			}.Encode(),
+		},
+		{
+			name: "Add $count and headers",
+			opts: []ListQueryOption{
+				ListWithCount(new(int)),
+				ListWithHeader("Prefer", "outlook.timezone=\"UTC\""),
+			},
+			wantValues: url.Values{
+				"$count": []string{"true"},
+			}.Encode(),
+			wantHeaders: map[string]string{
+				"ConsistencyLevel": "eventual",
+				"Prefer":           "outlook.timezone=\"UTC\"",
+			},
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
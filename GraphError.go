@@ -0,0 +1,138 @@
+package msgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// GraphError represents an error response of the ms graph API or of the Azure AD token endpoint.
+// All API-calls of GraphClient return a *GraphError if the HTTP StatusCode is not 2xx, hence
+// use errors.As to retrieve the details or errors.Is with ErrNotFound, ErrForbidden, ErrThrottled,
+// ErrConflict, ErrPreconditionFailed or ErrUnauthorized to branch on the kind of error.
+//
+// See https://docs.microsoft.com/en-us/graph/errors
+type GraphError struct {
+	StatusCode int             // the HTTP StatusCode of the response, e.g. 404
+	Code       string          // the error code, e.g. "Request_ResourceNotFound" or "invalid_client" for token errors
+	Message    string          // the human readable error message
+	InnerError GraphInnerError // additional details about the error, e.g. the request-id
+	Header     http.Header     // the response headers, e.g. to read Retry-After
+	Body       []byte          // the raw response body
+	Retries    int             // the number of retries performed according to the RetryPolicy before giving up
+}
+
+// GraphInnerError contains additional details of a GraphError as returned by the ms graph API
+type GraphInnerError struct {
+	Code            string    // a more specific error code, if any
+	RequestID       string    // the request-id of the failed request, use it when contacting Microsoft support
+	ClientRequestID string    // the client-request-id of the failed request
+	Date            time.Time // the date when the error occurred
+}
+
+func (e *GraphError) Error() string {
+	if e.Code == "" && e.Message == "" {
+		// Hint: this will mostly be the case if the body is not a json error envelope, e.g. a HTML-page of a proxy.
+		// The cause will be described in the body, hence we have to return the body too for proper error-analysis
+		return fmt.Sprintf("StatusCode is not OK: %v. Body: %v ", e.StatusCode, string(e.Body))
+	}
+	return fmt.Sprintf("StatusCode is not OK: %v. Code: %v, Message: %v, RequestID: %v",
+		e.StatusCode, e.Code, e.Message, e.RequestID())
+}
+
+// Is reports whether the GraphError matches the given target. Supported targets are ErrNotFound,
+// ErrForbidden, ErrThrottled, ErrConflict, ErrPreconditionFailed, ErrUnauthorized and ErrResyncRequired,
+// all matched by the HTTP StatusCode. This func is used by errors.Is.
+func (e *GraphError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrThrottled:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrPreconditionFailed:
+		return e.StatusCode == http.StatusPreconditionFailed
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrResyncRequired:
+		return e.StatusCode == http.StatusGone
+	}
+	return false
+}
+
+// RequestID returns the request-id of the failed request. The InnerError is preferred, if it is
+// empty the response header "request-id" is used.
+func (e *GraphError) RequestID() string {
+	if e.InnerError.RequestID != "" {
+		return e.InnerError.RequestID
+	}
+	return e.Header.Get("request-id")
+}
+
+// newGraphError creates a GraphError from the given response and its already read body. The body
+// is parsed either as the ms graph error envelope {"error":{"code","message","innerError"}} or as
+// an OAuth2 error {"error","error_description"} as returned by the token endpoint.
+func newGraphError(resp *http.Response, body []byte) *GraphError {
+	graphErr := &GraphError{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+
+	var envelope struct {
+		Error            json.RawMessage `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+		TraceID          string          `json:"trace_id"`
+		CorrelationID    string          `json:"correlation_id"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || len(envelope.Error) == 0 {
+		return graphErr
+	}
+
+	// OAuth2 error of the token endpoint, "error" is a plain string
+	var oauthCode string
+	if err := json.Unmarshal(envelope.Error, &oauthCode); err == nil {
+		graphErr.Code = oauthCode
+		graphErr.Message = envelope.ErrorDescription
+		graphErr.InnerError.RequestID = envelope.TraceID
+		graphErr.InnerError.ClientRequestID = envelope.CorrelationID
+		return graphErr
+	}
+
+	var graphEnvelope struct {
+		Code       string `json:"code"`
+		Message    string `json:"message"`
+		InnerError struct {
+			Code            string `json:"code"`
+			RequestID       string `json:"request-id"`
+			ClientRequestID string `json:"client-request-id"`
+			Date            string `json:"date"`
+		} `json:"innerError"`
+	}
+	if err := json.Unmarshal(envelope.Error, &graphEnvelope); err != nil {
+		return graphErr
+	}
+	graphErr.Code = graphEnvelope.Code
+	graphErr.Message = graphEnvelope.Message
+	graphErr.InnerError.Code = graphEnvelope.InnerError.Code
+	graphErr.InnerError.RequestID = graphEnvelope.InnerError.RequestID
+	graphErr.InnerError.ClientRequestID = graphEnvelope.InnerError.ClientRequestID
+	graphErr.InnerError.Date = parseGraphErrorDate(graphEnvelope.InnerError.Date)
+	return graphErr
+}
+
+// parseGraphErrorDate parses the date of an innerError. The ms graph API returns it either in RFC3339
+// or without any timezone, which is UTC then. Returns the zero time.Time if it cannot be parsed.
+func parseGraphErrorDate(date string) time.Time {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", date, time.UTC); err == nil {
+		return t
+	}
+	return time.Time{}
+}
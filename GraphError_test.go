@@ -0,0 +1,122 @@
+package msgraph
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGraphError_newGraphError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		header     http.Header
+		body       string
+		wantCode   string
+		wantMsg    string
+		wantReqID  string
+		wantDate   time.Time
+		wantIs     error
+		wantIsNot  error
+	}{
+		{
+			name:       "Graph error envelope",
+			statusCode: http.StatusNotFound,
+			body:       `{"error":{"code":"Request_ResourceNotFound","message":"Resource 'x' does not exist.","innerError":{"request-id":"4a8e0e5c-1f2a-4a3b-9c7d-1e2f3a4b5c6d","date":"2021-06-01T10:11:12"}}}`,
+			wantCode:   "Request_ResourceNotFound",
+			wantMsg:    "Resource 'x' does not exist.",
+			wantReqID:  "4a8e0e5c-1f2a-4a3b-9c7d-1e2f3a4b5c6d",
+			wantDate:   time.Date(2021, 6, 1, 10, 11, 12, 0, time.UTC),
+			wantIs:     ErrNotFound,
+		}, {
+			name:       "OAuth2 error of token endpoint",
+			statusCode: http.StatusUnauthorized,
+			body:       `{"error":"invalid_client","error_description":"AADSTS7000215: Invalid client secret provided.","trace_id":"trace"}`,
+			wantCode:   "invalid_client",
+			wantMsg:    "AADSTS7000215: Invalid client secret provided.",
+			wantReqID:  "trace",
+			wantIs:     ErrUnauthorized,
+		}, {
+			name:       "Throttled with request-id header only",
+			statusCode: http.StatusTooManyRequests,
+			header:     http.Header{"Request-Id": []string{"from-header"}},
+			body:       `{"error":{"code":"TooManyRequests","message":"Too many requests"}}`,
+			wantCode:   "TooManyRequests",
+			wantMsg:    "Too many requests",
+			wantReqID:  "from-header",
+			wantIs:     ErrThrottled,
+		}, {
+			name:       "Forbidden",
+			statusCode: http.StatusForbidden,
+			body:       `{"error":{"code":"Authorization_RequestDenied","message":"Insufficient privileges"}}`,
+			wantCode:   "Authorization_RequestDenied",
+			wantMsg:    "Insufficient privileges",
+			wantIs:     ErrForbidden,
+		}, {
+			name:       "Conflict with non-json body",
+			statusCode: http.StatusConflict,
+			body:       `<html>conflict</html>`,
+			wantIs:     ErrConflict,
+			wantIsNot:  ErrPreconditionFailed,
+		}, {
+			name:       "Precondition failed",
+			statusCode: http.StatusPreconditionFailed,
+			body:       `{"error":{"code":"ErrorIrresolvableConflict","message":"The change key does not match"}}`,
+			wantCode:   "ErrorIrresolvableConflict",
+			wantMsg:    "The change key does not match",
+			wantIs:     ErrPreconditionFailed,
+			wantIsNot:  ErrConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.header == nil {
+				tt.header = http.Header{}
+			}
+			got := newGraphError(&http.Response{StatusCode: tt.statusCode, Header: tt.header}, []byte(tt.body))
+			if got.Code != tt.wantCode || got.Message != tt.wantMsg || got.RequestID() != tt.wantReqID {
+				t.Errorf("newGraphError() = {Code: %v, Message: %v, RequestID: %v}, want {Code: %v, Message: %v, RequestID: %v}",
+					got.Code, got.Message, got.RequestID(), tt.wantCode, tt.wantMsg, tt.wantReqID)
+			}
+			if !got.InnerError.Date.Equal(tt.wantDate) {
+				t.Errorf("newGraphError() InnerError.Date = %v, want %v", got.InnerError.Date, tt.wantDate)
+			}
+			var err error = fmt.Errorf("wrapped: %w", got)
+			if !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", err, tt.wantIs)
+			}
+			if tt.wantIsNot != nil && errors.Is(err, tt.wantIsNot) {
+				t.Errorf("errors.Is(%v, %v) = true, want false", err, tt.wantIsNot)
+			}
+			var graphErr *GraphError
+			if !errors.As(err, &graphErr) || graphErr.StatusCode != tt.statusCode {
+				t.Errorf("errors.As() did not return the GraphError with StatusCode %v", tt.statusCode)
+			}
+		})
+	}
+}
+
+func TestGraphError_GetUser(t *testing.T) {
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("request-id", "abc")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":{"code":"Request_ResourceNotFound","message":"not found"}}`)
+	})
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL)
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+	_, err = g.GetUser("nobody@contoso.com")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("GraphClient.GetUser() error = %v, want ErrNotFound", err)
+	}
+	if errors.Is(err, ErrForbidden) {
+		t.Errorf("GraphClient.GetUser() error = %v must not match ErrForbidden", err)
+	}
+	var graphErr *GraphError
+	if !errors.As(err, &graphErr) || graphErr.RequestID() != "abc" {
+		t.Errorf("GraphClient.GetUser() error = %v, want GraphError with RequestID abc", err)
+	}
+}
@@ -45,16 +45,18 @@ func (g *Group) setGraphClient(gC *GraphClient) {
 //
 // See https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/group_list_members
 func (g Group) ListMembers(opts ...ListQueryOption) (Users, error) {
-	if g.graphClient == nil {
-		return nil, ErrNotGraphClientSourced
-	}
-	resource := fmt.Sprintf("/groups/%v/members", g.ID)
+	return NewCollection[User](g.graphClient, fmt.Sprintf("/groups/%v/members", g.ID)).List(opts...)
+}
 
-	var marsh struct {
-		Users Users `json:"value"`
-	}
-	marsh.Users.setGraphClient(g.graphClient)
-	return marsh.Users, g.graphClient.makeGETAPICall(resource, compileListQueryOptions(opts), &marsh)
+// IterateMembers returns an UsersIterator to load the group's direct members page by page instead of
+// loading all of them at once like ListMembers. Use ListWithPageSize to set the page size and
+// ListWithNextLink to resume. The iterator returns ErrNotGraphClientSourced if the group has not been
+// created by a GraphClient.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+//
+// See https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/group_list_members
+func (g Group) IterateMembers(opts ...ListQueryOption) *UsersIterator {
+	return &UsersIterator{NewCollection[User](g.graphClient, fmt.Sprintf("/groups/%v/members", g.ID)).Iterate(opts...)}
 }
 
 // UnmarshalJSON implements the json unmarshal to be used by the json-library
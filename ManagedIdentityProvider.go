@@ -0,0 +1,105 @@
+package msgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// IMDSEndpoint is the token endpoint of the Azure Instance Metadata Service, used by the ManagedIdentityProvider
+// on virtual machines, scale sets and AKS
+const IMDSEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+// ManagedIdentityProvider acquires application Tokens for the managed identity of the Azure resource the
+// application runs on, hence neither a ClientSecret nor a certificate is required. Both the Instance Metadata
+// Service (IMDS) and the App Service / Azure Functions endpoint given by IDENTITY_ENDPOINT and IDENTITY_HEADER
+// are supported.
+//
+// See https://docs.microsoft.com/en-us/azure/active-directory/managed-identities-azure-resources/overview
+type ManagedIdentityProvider struct {
+	// ClientID of a user-assigned managed identity, leave ClientID and ResourceID empty for the system-assigned one
+	ClientID string
+	// ResourceID, i.e. the Azure resource ID, of a user-assigned managed identity as alternative to the ClientID
+	ResourceID string
+	// Endpoint is the token endpoint, IDENTITY_ENDPOINT if set, otherwise IMDSEndpoint. Override it e.g. for tests.
+	Endpoint string
+	// Header is the secret IDENTITY_HEADER sent to the App Service endpoint. If empty, the Endpoint is used like IMDS.
+	Header string
+
+	graphClient *GraphClient // the graphClient that uses this provider
+}
+
+// NewManagedIdentityProvider creates a new ManagedIdentityProvider for the system-assigned managed identity if
+// clientID is empty, otherwise for the user-assigned managed identity with that client ID. The endpoint is
+// detected from the environment variables IDENTITY_ENDPOINT and IDENTITY_HEADER, IMDS is used if not set.
+func NewManagedIdentityProvider(clientID string) *ManagedIdentityProvider {
+	p := &ManagedIdentityProvider{
+		ClientID: clientID,
+		Endpoint: os.Getenv("IDENTITY_ENDPOINT"),
+		Header:   os.Getenv("IDENTITY_HEADER"),
+	}
+	if p.Endpoint == "" || p.Header == "" {
+		p.Endpoint = IMDSEndpoint
+		p.Header = ""
+	}
+	return p
+}
+
+// setGraphClient sets the graphClient instance that uses this provider
+func (p *ManagedIdentityProvider) setGraphClient(g *GraphClient) {
+	p.graphClient = g
+}
+
+// Token implements TokenProvider
+func (p *ManagedIdentityProvider) Token(ctx context.Context) (Token, error) {
+	if p.graphClient == nil {
+		return Token{}, errTokenProviderNotGraphClientSourced
+	}
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = IMDSEndpoint
+	}
+	u, err := url.ParseRequestURI(endpoint)
+	if err != nil {
+		return Token{}, fmt.Errorf("unable to parse URI: %v", err)
+	}
+
+	query := u.Query()
+	query.Set("resource", p.graphClient.serviceRootEndpoint)
+	if p.Header != "" {
+		query.Set("api-version", "2019-08-01")
+		if p.ResourceID != "" {
+			query.Set("mi_res_id", p.ResourceID)
+		}
+	} else {
+		query.Set("api-version", "2018-02-01")
+		if p.ResourceID != "" {
+			query.Set("msi_res_id", p.ResourceID)
+		}
+	}
+	if p.ClientID != "" {
+		query.Set("client_id", p.ClientID)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Token{}, fmt.Errorf("HTTP Request Error: %v", err)
+	}
+	if p.Header != "" {
+		req.Header.Set("X-IDENTITY-HEADER", p.Header)
+	} else {
+		req.Header.Set("Metadata", "true")
+	}
+
+	_, body, err := p.graphClient.doRequest(req) // retries according to the RetryPolicy, returns a GraphError if the StatusCode is not OK
+	if err != nil {
+		return Token{}, err
+	}
+	var token Token
+	err = json.Unmarshal(body, &token)
+	return token, err
+}
@@ -0,0 +1,67 @@
+package msgraph
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManagedIdentityProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   ManagedIdentityProvider
+		wantQuery  string
+		wantHeader string
+	}{
+		{name: "IMDS system-assigned", wantQuery: "api-version=2018-02-01&resource={{srv}}", wantHeader: "Metadata: true"},
+		{name: "IMDS user-assigned", provider: ManagedIdentityProvider{ClientID: "mi-client"},
+			wantQuery: "api-version=2018-02-01&client_id=mi-client&resource={{srv}}", wantHeader: "Metadata: true"},
+		{name: "App Service", provider: ManagedIdentityProvider{ResourceID: "/subscriptions/s/mi", Header: "secret"},
+			wantQuery: "api-version=2019-08-01&mi_res_id=/subscriptions/s/mi&resource={{srv}}", wantHeader: "X-Identity-Header: secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var srvURL string
+			srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				header := strings.SplitN(tt.wantHeader, ": ", 2)
+				query, _ := url.QueryUnescape(r.URL.RawQuery)
+				if r.Method != http.MethodGet || r.URL.Path != "/metadata/identity/oauth2/token" ||
+					query != strings.Replace(tt.wantQuery, "{{srv}}", srvURL, 1) || r.Header.Get(header[0]) != header[1] {
+					t.Errorf("token request %v %v %v with headers %v, want %v with %v", r.Method, r.URL.Path, query, r.Header, tt.wantQuery, tt.wantHeader)
+				}
+				// the metadata endpoints return all numbers as strings
+				fmt.Fprintf(w, `{"access_token":"mi-token","expires_in":"3599","expires_on":"%d","not_before":"%d","resource":"%v","token_type":"Bearer"}`,
+					time.Now().Add(time.Hour).Unix(), time.Now().Add(-time.Minute).Unix(), srvURL)
+			}, func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer mi-token" {
+					t.Errorf("Authorization header = %v, want Bearer mi-token", r.Header.Get("Authorization"))
+				}
+				fmt.Fprint(w, `{"id":"1"}`)
+			})
+			srvURL = srv.URL
+
+			provider := tt.provider
+			provider.Endpoint = srv.URL + "/metadata/identity/oauth2/token"
+			g, err := NewGraphClientWithTokenProvider(&provider, ClientWithEndpoints(srv.URL, srv.URL))
+			if err != nil {
+				t.Fatalf("NewGraphClientWithTokenProvider() error = %v", err)
+			}
+			if _, err := g.GetUser("1"); err != nil {
+				t.Errorf("GraphClient.GetUser() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewManagedIdentityProvider(t *testing.T) {
+	provider := NewManagedIdentityProvider("")
+	if provider.Endpoint == IMDSEndpoint && provider.Header != "" {
+		t.Errorf("NewManagedIdentityProvider() uses IMDS with a Header %v", provider.Header)
+	}
+	if provider.Endpoint == "" {
+		t.Errorf("NewManagedIdentityProvider() Endpoint is empty, want IMDSEndpoint or IDENTITY_ENDPOINT")
+	}
+}
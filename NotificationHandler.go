@@ -0,0 +1,101 @@
+package msgraph
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxNotificationBodySize limits the size of a notification request read by the NotificationHandler
+const maxNotificationBodySize = 4 << 20
+
+// ChangeNotification represents a single notification sent by the ms graph API to the NotificationURL
+// of a Subscription, either about a changed resource or, if LifecycleEvent is set, about the subscription itself.
+//
+// See https://docs.microsoft.com/en-us/graph/api/resources/changenotification
+type ChangeNotification struct {
+	ID                             string                         `json:"id"`
+	SubscriptionID                 string                         `json:"subscriptionId"`
+	SubscriptionExpirationDateTime time.Time                      `json:"subscriptionExpirationDateTime"`
+	ChangeType                     string                         `json:"changeType"` // "created", "updated" or "deleted"
+	Resource                       string                         `json:"resource"`   // e.g. "Users/{id}"
+	ResourceData                   ChangeNotificationResourceData `json:"resourceData"`
+	ClientState                    string                         `json:"clientState"`
+	TenantID                       string                         `json:"tenantId"`
+	LifecycleEvent                 string                         `json:"lifecycleEvent"` // "reauthorizationRequired", "subscriptionRemoved" or "missed", empty for changes
+}
+
+// ChangeNotificationResourceData identifies the changed resource of a ChangeNotification
+type ChangeNotificationResourceData struct {
+	ODataType string `json:"@odata.type"` // e.g. "#Microsoft.Graph.User"
+	ODataID   string `json:"@odata.id"`   // e.g. "Users/{id}"
+	ID        string `json:"id"`
+}
+
+// NotificationHandler is a http.Handler that receives the change notifications of Subscriptions, it must be
+// reachable at the NotificationURL respectively the LifecycleNotificationURL of the Subscription.
+//
+// It answers the validationToken handshake when a Subscription is created, verifies the ClientState of every
+// notification and dispatches them to OnNotification respectively OnLifecycleNotification. If any callback
+// returns an error, the request is answered with 500, hence the ms graph API sends all notifications of that
+// request again.
+//
+// See https://docs.microsoft.com/en-us/graph/webhooks
+type NotificationHandler struct {
+	// ClientState must match the ClientState of every notification, otherwise the request is rejected with 403.
+	// Leave it empty only if the Subscriptions have been created without a ClientState.
+	ClientState string
+	// OnNotification is called for every notification about a changed resource, may be nil
+	OnNotification func(ctx context.Context, notification ChangeNotification) error
+	// OnLifecycleNotification is called for every lifecycle notification, e.g. "reauthorizationRequired", may be nil
+	OnLifecycleNotification func(ctx context.Context, notification ChangeNotification) error
+}
+
+// ServeHTTP implements http.Handler
+func (h *NotificationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the ms graph API validates the NotificationURL by sending a validationToken that must be echoed as plain text
+	if validationToken := r.URL.Query().Get("validationToken"); validationToken != "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, validationToken)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload struct {
+		Value []ChangeNotification `json:"value"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxNotificationBodySize)).Decode(&payload); err != nil {
+		http.Error(w, "cannot decode notifications", http.StatusBadRequest)
+		return
+	}
+	for _, notification := range payload.Value {
+		if subtle.ConstantTimeCompare([]byte(notification.ClientState), []byte(h.ClientState)) != 1 {
+			http.Error(w, "invalid clientState", http.StatusForbidden)
+			return
+		}
+	}
+
+	for _, notification := range payload.Value {
+		callback := h.OnNotification
+		if notification.LifecycleEvent != "" {
+			callback = h.OnLifecycleNotification
+		}
+		if callback == nil {
+			continue
+		}
+		if err := callback(r.Context(), notification); err != nil {
+			http.Error(w, "cannot process notifications", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
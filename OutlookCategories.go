@@ -10,7 +10,8 @@ type OutlookCategories []OutlookCategory
 
 func (t OutlookCategories) setGraphClient(u *User) OutlookCategories {
 	for i := range t {
-		t[i] = t[i].setGraphClient(u)
+		t[i].setGraphClient(u.graphClient)
+		t[i].user = u
 	}
 	return t
 }
@@ -1,7 +1,6 @@
 package msgraph
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 )
@@ -13,13 +12,13 @@ type OutlookCategory struct {
 	ID 			string 	`json:"id"`
 	DisplayName string 	`json:"displayName"`
 	Color 		string 	`json:"color"`
+	ETag 		string 	`json:"-"` // the @odata.etag at read time if any, see UpdateWithConcurrencyCheck
 }
 
 
-func (t OutlookCategory) setGraphClient(u *User) OutlookCategory {
-	t.graphClient = u.graphClient
-	t.user = u
-	return t
+// setGraphClient sets the graphClient instance in this instance and all child-instances (if any)
+func (t *OutlookCategory) setGraphClient(gC *GraphClient) {
+	t.graphClient = gC
 }
 
 func (t OutlookCategory) String() string {
@@ -27,30 +26,59 @@ func (t OutlookCategory) String() string {
 }
 
 func (t OutlookCategory) Delete(opts ...DeleteQueryOption) error {
-	if t.graphClient == nil {
+	if t.graphClient == nil || t.user == nil {
 		return ErrNotGraphClientSourced
 	}
-
-	resource := fmt.Sprintf("/users/%v/outlook/masterCategories/%v", t.user.ID, t.ID)
-
-	err := t.graphClient.makeDELETEAPICall(resource, compileDeleteQueryOptions(opts), nil)
-	return err
+	return t.item().Delete(opts...)
 }
 
+// Update patches the category with all its properties. Pass UpdateWithConcurrencyCheck to send the ETag captured
+// at read time as If-Match, hence the update fails with ErrPreconditionFailed instead of overwriting concurrent
+// changes. See Mutate to retry in that case.
 func (t *OutlookCategory) Update(opts ...UpdateQueryOption) error {
-	if t.graphClient == nil {
+	if t.graphClient == nil || t.user == nil {
 		return ErrNotGraphClientSourced
 	}
+	// TODO: check return body, maybe there is some potential success or error message hidden in it?
+	return t.item().Update(t, append(opts, updateWithETag(t.ETag))...)
+}
 
-	resource := fmt.Sprintf("/users/%v/outlook/masterCategories/%v", t.user.ID, t.ID)
-
-	bodyBytes, err := json.Marshal(t)
+// Mutate re-reads the category, applies the given mutate func to it and updates it if it has not been changed
+// in the meantime, otherwise it retries, see Item.Mutate. The category passed to mutate belongs to the same User
+// as this one. The category is set to the updated one afterwards.
+func (t *OutlookCategory) Mutate(mutate func(category *OutlookCategory) error, opts ...UpdateQueryOption) error {
+	if t.graphClient == nil || t.user == nil {
+		return ErrNotGraphClientSourced
+	}
+	updated, err := t.item().Mutate(func(category *OutlookCategory) error {
+		category.user = t.user // Hint: not set by the generic layer, not marshalled hence not part of the changes
+		return mutate(category)
+	}, opts...)
 	if err != nil {
 		return err
 	}
-	reader := bytes.NewReader(bodyBytes)
+	updated.user = t.user
+	*t = updated
+	return nil
+}
 
-	// TODO: check return body, maybe there is some potential success or error message hidden in it?
-	err = t.graphClient.makePATCHAPICall(resource, compileUpdateQueryOptions(opts), reader, nil)
-	return err
-}
\ No newline at end of file
+// UnmarshalJSON implements the json unmarshal to be used by the json-library, it captures the @odata.etag
+func (t *OutlookCategory) UnmarshalJSON(data []byte) error {
+	type outlookCategory OutlookCategory // Hint: without the UnmarshalJSON method, hence no recursion
+	tmp := struct {
+		outlookCategory
+		ETag string `json:"@odata.etag"`
+	}{outlookCategory: outlookCategory(*t)}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	*t = OutlookCategory(tmp.outlookCategory)
+	t.ETag = tmp.ETag
+	return nil
+}
+
+// item returns the Item of this category for the generic API-calls. The category must have been loaded by a
+// User, e.g. with User.ListCategories, hence t.user is set: the generic layer only sets the GraphClient.
+func (t OutlookCategory) item() Item[OutlookCategory] {
+	return NewItem[OutlookCategory](t.graphClient, fmt.Sprintf("/users/%v/outlook/masterCategories/%v", t.user.ID, t.ID))
+}
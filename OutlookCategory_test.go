@@ -0,0 +1,68 @@
+package msgraph
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/SerenityITS-Development/go-msgraph/msgraphtest"
+)
+
+func TestOutlookCategory_Update(t *testing.T) {
+	srv, g := newTestClient(t)
+	alice := srv.AddUser(msgraphtest.Object{"displayName": "Alice", "userPrincipalName": "alice@contoso.test"})
+	srv.AddCategory(alice["id"].(string), msgraphtest.Object{"displayName": "Project", "color": "preset0"})
+	user, err := g.GetUser(alice["id"].(string))
+	if err != nil {
+		t.Fatalf("GraphClient.GetUser() error = %v", err)
+	}
+	first, err := user.ListCategories()
+	if err != nil || len(first) != 1 || first[0].ETag == "" {
+		t.Fatalf("User.ListCategories() = %v, %v, want one category with ETag", first, err)
+	}
+	second, _ := user.ListCategories()
+
+	first[0].Color = "preset1"
+	if err := first[0].Update(UpdateWithConcurrencyCheck()); err != nil {
+		t.Errorf("OutlookCategory.Update() error = %v", err)
+	}
+	second[0].Color = "preset2"
+	err = second[0].Update(UpdateWithConcurrencyCheck())
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("OutlookCategory.Update() of a changed category error = %v, want ErrPreconditionFailed", err)
+	}
+
+	if err := second[0].Mutate(func(category *OutlookCategory) error {
+		if category.user == nil || category.graphClient == nil {
+			return fmt.Errorf("category %v without user or GraphClient", category)
+		}
+		category.Color = "preset2"
+		return nil
+	}); err != nil {
+		t.Fatalf("OutlookCategory.Mutate() error = %v", err)
+	}
+	if second[0].Color != "preset2" || second[0].user == nil {
+		t.Errorf("OutlookCategory.Mutate() = %v, want Color preset2 with user", second[0])
+	}
+	if obj, _ := srv.Get("users/"+alice["id"].(string)+"/outlook/masterCategories", first[0].ID); obj["color"] != "preset2" {
+		t.Errorf("updated category = %v, want color preset2", obj)
+	}
+
+	// without the concurrency check the update overwrites concurrent changes
+	if err := first[0].Update(); err != nil {
+		t.Errorf("OutlookCategory.Update() without concurrency check error = %v", err)
+	}
+	first[0].ETag = ""
+	if err := first[0].Update(UpdateWithConcurrencyCheck()); !errors.Is(err, ErrNoETag) {
+		t.Errorf("OutlookCategory.Update() without ETag error = %v, want ErrNoETag", err)
+	}
+
+	// a category loaded by the generic layer has no user, hence its path is unknown
+	category, err := NewItem[OutlookCategory](g, "/users/"+alice["id"].(string)+"/outlook/masterCategories/"+first[0].ID).Get()
+	if err != nil {
+		t.Fatalf("Item.Get() error = %v", err)
+	}
+	if err := category.Delete(); !errors.Is(err, ErrNotGraphClientSourced) {
+		t.Errorf("OutlookCategory.Delete() without user error = %v, want ErrNotGraphClientSourced", err)
+	}
+}
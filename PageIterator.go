@@ -0,0 +1,154 @@
+package msgraph
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// pageIterator loads the pages of a list API-call one after another by following the @odata.nextLink.
+// It is embedded by the typed iterators, e.g. UsersIterator, which unmarshal the pages.
+//
+// See https://docs.microsoft.com/en-us/graph/paging
+type pageIterator struct {
+	graphClient *GraphClient
+	resource    string            // the API-call of the first page, e.g. /users
+	reqParams   *listQueryOptions // query options of the first page, headers are sent with every page
+	nextLink    string            // the @odata.nextLink of the next page, empty for the first page
+	started     bool              // true as soon as the first page has been requested
+	loaded      int               // the number of results loaded so far, to stop at ListWithTop
+	done        bool              // true if the last page has been loaded or an error occurred
+}
+
+// newPageIterator creates a new pageIterator for the given resource. If a nextLink has been set with
+// ListWithNextLink, the iterator resumes at that page.
+func newPageIterator(graphClient *GraphClient, resource string, reqParams *listQueryOptions) pageIterator {
+	return pageIterator{
+		graphClient: graphClient,
+		resource:    resource,
+		reqParams:   reqParams,
+		nextLink:    reqParams.nextLink,
+		started:     reqParams.nextLink != "",
+	}
+}
+
+// HasNext returns true if there is another page to load with Next
+func (p *pageIterator) HasNext() bool {
+	return !p.done
+}
+
+// NextLink returns the @odata.nextLink of the next page. It can be persisted and passed to
+// ListWithNextLink to resume iterating at the next page later on. Returns an empty string
+// if there are no more pages.
+func (p *pageIterator) NextLink() string {
+	if p.done {
+		return ""
+	}
+	return p.nextLink
+}
+
+// nextPage loads the next page and json-unmarshals the whole response body into v. Returns
+// ErrNoMorePages if the last page has already been loaded.
+func (p *pageIterator) nextPage(v interface{}) error {
+	if p.done {
+		return ErrNoMorePages
+	}
+	if p.graphClient == nil {
+		p.done = true
+		return ErrNotGraphClientSourced
+	}
+
+	var body json.RawMessage
+	var err error
+	if !p.started {
+		p.started = true
+		var req *http.Request
+		req, err = p.graphClient.newAPIRequest(p.resource, http.MethodGet, p.reqParams, nil)
+		if err == nil {
+			err = p.graphClient.performSkipTokenRequest(req, &body)
+		}
+	} else {
+		err = p.graphClient.makeSkipTokenApiCall(p.reqParams.Context(), http.MethodGet, &body, p.nextLink, p.reqParams.Headers())
+	}
+	if err != nil {
+		p.done = true
+		return err
+	}
+
+	var page struct {
+		NextLink string            `json:"@odata.nextLink"`
+		Count    *int              `json:"@odata.count"`
+		Value    []json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		p.done = true
+		return err
+	}
+	p.nextLink = page.NextLink
+	p.done = page.NextLink == ""
+	if p.reqParams.count != nil && page.Count != nil {
+		*p.reqParams.count = *page.Count
+	}
+	if top := p.reqParams.top; top > 0 {
+		if remaining := top - p.loaded; len(page.Value) >= remaining {
+			// Hint: ListWithTop has been reached, hence drop the rest of this page and stop
+			p.done = true
+			body, err = json.Marshal(struct {
+				Value []json.RawMessage `json:"value"`
+			}{page.Value[:remaining]})
+			if err != nil {
+				return err
+			}
+		}
+		p.loaded += len(page.Value)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// UsersIterator loads Users page by page, see GraphClient.IterateUsers and Group.IterateMembers
+type UsersIterator struct {
+	Iterator[User]
+}
+
+// Next loads and returns the next page of Users. Returns ErrNoMorePages if HasNext is false.
+func (it *UsersIterator) Next() (Users, error) {
+	return it.Iterator.Next()
+}
+
+// GroupsIterator loads Groups page by page, see GraphClient.IterateGroups
+type GroupsIterator struct {
+	Iterator[Group]
+}
+
+// Next loads and returns the next page of Groups. Returns ErrNoMorePages if HasNext is false.
+func (it *GroupsIterator) Next() (Groups, error) {
+	return it.Iterator.Next()
+}
+
+// CalendarEventsIterator loads CalendarEvents page by page, see User.IterateCalendarView
+type CalendarEventsIterator struct {
+	pageIterator
+}
+
+// Next loads and returns the next page of CalendarEvents, sorted by StartDateTime within the page.
+// Returns ErrNoMorePages if HasNext is false.
+func (it *CalendarEventsIterator) Next() (CalendarEvents, error) {
+	var calendarEvents CalendarEvents
+	if err := it.nextPage(&calendarEvents); err != nil {
+		return nil, err
+	}
+	return calendarEvents.setGraphClient(it.graphClient), nil
+}
+
+// AlertsIterator loads security Alerts page by page, see GraphClient.IterateAlerts
+type AlertsIterator struct {
+	pageIterator
+}
+
+// Next loads and returns the next page of Alerts. Returns ErrNoMorePages if HasNext is false.
+func (it *AlertsIterator) Next() ([]Alert, error) {
+	var marsh struct {
+		Alerts []Alert `json:"value"`
+	}
+	err := it.nextPage(&marsh)
+	return marsh.Alerts, err
+}
@@ -0,0 +1,149 @@
+package msgraph
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUsersIterator(t *testing.T) {
+	// serves 5 users in pages of the requested $top
+	var srv *httptest.Server
+	srv = newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		top, _ := strconv.Atoi(r.URL.Query().Get("$top"))
+		skip, _ := strconv.Atoi(r.URL.Query().Get("$skiptoken"))
+		if top <= 0 {
+			t.Errorf("request %v without $top", r.URL)
+			top = 5
+		}
+		var values []string
+		for i := skip; i < skip+top && i < 5; i++ {
+			values = append(values, fmt.Sprintf(`{"id":"%d"}`, i))
+		}
+		var nextLink string
+		if skip+top < 5 {
+			nextLink = fmt.Sprintf(`,"@odata.nextLink":"%v/v1.0/users?$top=%d&$skiptoken=%d"`, srv.URL, top, skip+top)
+		}
+		fmt.Fprintf(w, `{"value":[%v]%v}`, strings.Join(values, ","), nextLink)
+	})
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL)
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+
+	t.Run("iterate all pages", func(t *testing.T) {
+		it := g.IterateUsers(ListWithPageSize(2))
+		var pageSizes []int
+		for it.HasNext() {
+			users, err := it.Next()
+			if err != nil {
+				t.Fatalf("UsersIterator.Next() error = %v", err)
+			}
+			for _, user := range users {
+				if user.graphClient == nil {
+					t.Errorf("UsersIterator.Next() graphClient is nil, but was initialized from GraphClient")
+				}
+			}
+			pageSizes = append(pageSizes, len(users))
+		}
+		if fmt.Sprint(pageSizes) != "[2 2 1]" {
+			t.Errorf("UsersIterator page sizes = %v, want [2 2 1]", pageSizes)
+		}
+		if _, err := it.Next(); !errors.Is(err, ErrNoMorePages) {
+			t.Errorf("UsersIterator.Next() after last page error = %v, want ErrNoMorePages", err)
+		}
+		if it.NextLink() != "" {
+			t.Errorf("UsersIterator.NextLink() after last page = %v, want empty", it.NextLink())
+		}
+	})
+
+	t.Run("stop early and resume", func(t *testing.T) {
+		it := g.IterateUsers(ListWithPageSize(2))
+		if _, err := it.Next(); err != nil {
+			t.Fatalf("UsersIterator.Next() error = %v", err)
+		}
+		nextLink := it.NextLink()
+		if nextLink == "" {
+			t.Fatalf("UsersIterator.NextLink() is empty, but there are more pages")
+		}
+
+		resumed := g.IterateUsers(ListWithNextLink(nextLink))
+		users, err := resumed.Next()
+		if err != nil {
+			t.Fatalf("UsersIterator.Next() error = %v", err)
+		}
+		if len(users) != 2 || users[0].ID != "2" {
+			t.Errorf("resumed UsersIterator.Next() = %v, want users 2 and 3", users)
+		}
+	})
+
+	t.Run("ListUsers loads all pages", func(t *testing.T) {
+		users, err := g.ListUsers(ListWithPageSize(2))
+		if err != nil {
+			t.Fatalf("GraphClient.ListUsers() error = %v", err)
+		}
+		if len(users) != 5 {
+			t.Errorf("GraphClient.ListUsers() returned %d users, want 5", len(users))
+		}
+	})
+
+	t.Run("not GraphClient sourced", func(t *testing.T) {
+		it := Group{ID: "1"}.IterateMembers()
+		if _, err := it.Next(); err != ErrNotGraphClientSourced {
+			t.Errorf("UsersIterator.Next() error = %v, want ErrNotGraphClientSourced", err)
+		}
+	})
+}
+
+func TestGraphClient_ListUsers_topAndCount(t *testing.T) {
+	var srv *httptest.Server
+	srv = newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("ConsistencyLevel") != "eventual" || r.Header.Get("Prefer") != "test" {
+			t.Errorf("request %v headers = %v, want ConsistencyLevel and Prefer on every page", r.URL, r.Header)
+		}
+		query := r.URL.Query()
+		if query.Get("$orderby") != "displayName" || query.Get("$expand") != "manager" || query.Get("$count") != "true" {
+			t.Errorf("request %v, want $orderby, $expand and $count", r.URL)
+		}
+		skip, _ := strconv.Atoi(query.Get("$skiptoken"))
+		values := []string{fmt.Sprintf(`{"id":"%d"}`, skip), fmt.Sprintf(`{"id":"%d"}`, skip+1)}
+		fmt.Fprintf(w, `{"@odata.count":42,"value":[%v],"@odata.nextLink":"%v/v1.0/users?$orderby=displayName&$expand=manager&$count=true&$skiptoken=%d"}`,
+			strings.Join(values, ","), srv.URL, skip+2)
+	})
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL)
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+
+	var count int
+	opts := []ListQueryOption{ListWithOrderBy("displayName"), ListWithExpand("manager"), ListWithTop(5),
+		ListWithCount(&count), ListWithHeader("Prefer", "test")}
+	users, err := g.ListUsers(opts...)
+	if err != nil {
+		t.Fatalf("GraphClient.ListUsers() error = %v", err)
+	}
+	if len(users) != 5 || users[4].ID != "4" {
+		t.Errorf("GraphClient.ListUsers() = %v, want the first 5 users", users)
+	}
+	if count != 42 {
+		t.Errorf("ListWithCount() count = %v, want 42", count)
+	}
+
+	count = 0
+	it := g.IterateUsers(opts...)
+	var iterated Users
+	for it.HasNext() {
+		page, err := it.Next()
+		if err != nil {
+			t.Fatalf("UsersIterator.Next() error = %v", err)
+		}
+		iterated = append(iterated, page...)
+	}
+	if len(iterated) != 5 || count != 42 {
+		t.Errorf("UsersIterator returned %v users and count %v, want 5 users and count 42", len(iterated), count)
+	}
+}
@@ -13,7 +13,15 @@ type ResponseStatus struct {
 }
 
 func (s ResponseStatus) String() string {
-	return fmt.Sprintf("Response: %v, Time: %s", s.Response, s.Time.Format(time.RFC3339Nano))
+	var response EventResponseType
+	if s.Response != nil {
+		response = *s.Response
+	}
+	var responseTime string
+	if s.Time != nil {
+		responseTime = s.Time.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("Response: %s, Time: %s", response, responseTime)
 }
 
 // Equal compares the ResponseStatus to the other Response status and returns true
@@ -33,9 +41,12 @@ func (s *ResponseStatus) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
-	if *tmp.Response == "" {
+	if tmp.Response == nil || *tmp.Response == "" {
 		return fmt.Errorf("response-field is empty")
 	}
+	if tmp.Timestamp == nil {
+		return fmt.Errorf("time-field is empty")
+	}
 
 	s.Response = tmp.Response
 	tmpTime, err := time.Parse(time.RFC3339Nano, *tmp.Timestamp) // the timeZone is normally ALWAYS UTC, microsoft converts time date & time to that, but it does not matter here
@@ -197,6 +197,13 @@ func (g *GraphClient) ListAlerts(opts ...ListQueryOption) ([]Alert, error) {
 	return marsh.Alerts, err
 }
 
+// IterateAlerts returns an AlertsIterator to load the security alerts page by page instead of loading
+// all of them at once like ListAlerts. Use ListWithPageSize to set the page size and ListWithNextLink to resume.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+func (g *GraphClient) IterateAlerts(opts ...ListQueryOption) *AlertsIterator {
+	return &AlertsIterator{newPageIterator(g, "/security/alerts", compileListQueryOptions(opts))}
+}
+
 // SecureScore represents the security score of a tenant for a particular day.
 type SecureScore struct {
 	ID                       string                    `json:"id"`
@@ -0,0 +1,164 @@
+package msgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SubscriptionRenewRetryInterval is the time Subscription.AutoRenew waits before it retries a failed renewal
+var SubscriptionRenewRetryInterval = time.Minute
+
+// Subscription represents a subscription to change notifications of a resource, e.g. users or
+// /users/{id}/events. Notifications are sent to the NotificationURL, see NotificationHandler.
+//
+// See https://docs.microsoft.com/en-us/graph/api/resources/subscription
+type Subscription struct {
+	ID                        string    `json:"id,omitempty"`
+	Resource                  string    `json:"resource,omitempty"`        // e.g. "users", "groups" or "/users/{id}/messages"
+	ChangeType                string    `json:"changeType,omitempty"`      // comma-separated list of "created", "updated" and "deleted"
+	NotificationURL           string    `json:"notificationUrl,omitempty"` // https URL of the NotificationHandler
+	LifecycleNotificationURL  string    `json:"lifecycleNotificationUrl,omitempty"`
+	ClientState               string    `json:"clientState,omitempty"` // secret sent with every notification, verified by the NotificationHandler
+	ExpirationDateTime        time.Time `json:"expirationDateTime"`    // max. lifetime depends on the resource, e.g. 3 days for users, omitted if zero
+	ApplicationID             string    `json:"applicationId,omitempty"`
+	CreatorID                 string    `json:"creatorId,omitempty"`
+	LatestSupportedTLSVersion string    `json:"latestSupportedTlsVersion,omitempty"`
+
+	graphClient *GraphClient // the graphClient that created or loaded this subscription
+}
+
+func (s Subscription) String() string {
+	return fmt.Sprintf("Subscription(ID: \"%v\", Resource: \"%v\", ChangeType: \"%v\", NotificationURL: \"%v\", ExpirationDateTime: \"%v\", DirectAPIConnection: %v)",
+		s.ID, s.Resource, s.ChangeType, s.NotificationURL, s.ExpirationDateTime, s.graphClient != nil)
+}
+
+// MarshalJSON implements the json marshal to be used by the json-library. A zero ExpirationDateTime is omitted
+// because omitempty does not apply to a time.Time and the ms graph API rejects "0001-01-01T00:00:00Z".
+func (s Subscription) MarshalJSON() ([]byte, error) {
+	type subscription Subscription // Hint: without the MarshalJSON method, hence no recursion
+	tmp := struct {
+		subscription
+		ExpirationDateTime *time.Time `json:"expirationDateTime,omitempty"`
+	}{subscription: subscription(s)}
+	if !s.ExpirationDateTime.IsZero() {
+		tmp.ExpirationDateTime = &s.ExpirationDateTime
+	}
+	return json.Marshal(tmp)
+}
+
+// setGraphClient sets the graphClient instance in this instance and all child-instances (if any)
+func (s *Subscription) setGraphClient(gC *GraphClient) {
+	s.graphClient = gC
+}
+
+// Subscriptions represents multiple Subscriptions, used in JSON unmarshal
+type Subscriptions []Subscription
+
+// setGraphClient sets the graphClient instance in this instance and all child-instances (if any)
+func (s Subscriptions) setGraphClient(gC *GraphClient) Subscriptions {
+	for i := range s {
+		s[i].setGraphClient(gC)
+	}
+	return s
+}
+
+// CreateSubscription creates a new subscription to change notifications. Resource, ChangeType, NotificationURL
+// and ExpirationDateTime must be set. The ms graph API validates the NotificationURL before the subscription is
+// created, hence the NotificationHandler must already be reachable.
+//
+// Reference: https://docs.microsoft.com/en-us/graph/api/subscription-post-subscriptions
+func (g *GraphClient) CreateSubscription(subscriptionInput Subscription, opts ...CreateQueryOption) (Subscription, error) {
+	return g.subscriptions().Create(subscriptionInput, opts...)
+}
+
+// GetSubscription returns the subscription identified by the given ID
+//
+// Reference: https://docs.microsoft.com/en-us/graph/api/subscription-get
+func (g *GraphClient) GetSubscription(subscriptionID string, opts ...GetQueryOption) (Subscription, error) {
+	return g.subscriptions().Get(subscriptionID, opts...)
+}
+
+// ListSubscriptions returns all subscriptions of this application
+//
+// Reference: https://docs.microsoft.com/en-us/graph/api/subscription-list
+func (g *GraphClient) ListSubscriptions(opts ...ListQueryOption) (Subscriptions, error) {
+	return g.subscriptions().List(opts...)
+}
+
+// subscriptions returns the Collection of the subscriptions of this application
+func (g *GraphClient) subscriptions() Collection[Subscription] {
+	return NewCollection[Subscription](g, "/subscriptions")
+}
+
+// Renew extends the subscription until the given expirationDateTime and returns the renewed subscription.
+// Returns an error if the subscription is not GraphClient sourced or if there is any error during the API-call.
+//
+// Reference: https://docs.microsoft.com/en-us/graph/api/subscription-update
+func (s Subscription) Renew(expirationDateTime time.Time, opts ...UpdateQueryOption) (Subscription, error) {
+	if s.graphClient == nil {
+		return s, ErrNotGraphClientSourced
+	}
+	// Hint: the response body of the PATCH is not parsed, hence the new expirationDateTime is set here
+	err := s.graphClient.subscriptions().Item(s.ID).Update(struct {
+		ExpirationDateTime time.Time `json:"expirationDateTime"`
+	}{ExpirationDateTime: expirationDateTime}, opts...)
+	if err != nil {
+		return s, err
+	}
+	s.ExpirationDateTime = expirationDateTime
+	return s, nil
+}
+
+// Delete deletes the subscription, hence no further notifications are sent.
+// Returns an error if the subscription is not GraphClient sourced or if there is any error during the API-call.
+//
+// Reference: https://docs.microsoft.com/en-us/graph/api/subscription-delete
+func (s Subscription) Delete(opts ...DeleteQueryOption) error {
+	if s.graphClient == nil {
+		return ErrNotGraphClientSourced
+	}
+	return s.graphClient.subscriptions().Item(s.ID).Delete(opts...)
+}
+
+// AutoRenew keeps the subscription alive until ctx is done: renewBefore its ExpirationDateTime, the subscription
+// is renewed to expire after lifetime. onRenew may be nil, otherwise it is called after every renewal attempt
+// with the renewed subscription or the error. A failed renewal is retried after SubscriptionRenewRetryInterval.
+//
+// AutoRenew blocks, hence run it in its own goroutine. It returns the error of ctx when ctx is done, or the
+// last renewal error if the subscription has expired or does not exist anymore (ErrNotFound). Returns an error
+// right away unless lifetime > renewBefore > 0, otherwise every renewal would be due immediately.
+func (s Subscription) AutoRenew(ctx context.Context, lifetime, renewBefore time.Duration, onRenew func(Subscription, error)) error {
+	if s.graphClient == nil {
+		return ErrNotGraphClientSourced
+	}
+	if renewBefore <= 0 || lifetime <= renewBefore {
+		return fmt.Errorf("invalid renewal: lifetime %v must be longer than renewBefore %v, which must be positive", lifetime, renewBefore)
+	}
+	next := s.ExpirationDateTime.Add(-renewBefore)
+	for {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		renewed, err := s.Renew(time.Now().Add(lifetime), UpdateWithContext(ctx))
+		if onRenew != nil {
+			onRenew(renewed, err)
+		}
+		if err == nil {
+			s = renewed
+			next = s.ExpirationDateTime.Add(-renewBefore)
+			continue
+		}
+		if errors.Is(err, ErrNotFound) || time.Now().Add(SubscriptionRenewRetryInterval).After(s.ExpirationDateTime) {
+			return err
+		}
+		next = time.Now().Add(SubscriptionRenewRetryInterval)
+	}
+}
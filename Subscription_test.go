@@ -0,0 +1,161 @@
+package msgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotificationHandler(t *testing.T) {
+	var changes, lifecycles []ChangeNotification
+	handler := &NotificationHandler{
+		ClientState: "secret",
+		OnNotification: func(ctx context.Context, n ChangeNotification) error {
+			changes = append(changes, n)
+			if n.ResourceData.ID == "fail" {
+				return errors.New("cannot process")
+			}
+			return nil
+		},
+		OnLifecycleNotification: func(ctx context.Context, n ChangeNotification) error {
+			lifecycles = append(lifecycles, n)
+			return nil
+		},
+	}
+
+	t.Run("validationToken handshake", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/notify?validationToken=a%3Cb+c", nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != "a<b c" || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+			t.Errorf("NotificationHandler handshake = %v %q, want 200 with the plain validationToken", rec.Code, rec.Body.String())
+		}
+	})
+
+	tests := []struct {
+		name           string
+		body           string
+		wantCode       int
+		wantChanges    int
+		wantLifecycles int
+	}{
+		{name: "dispatch", wantCode: http.StatusAccepted, wantChanges: 1, wantLifecycles: 1,
+			body: `{"value":[{"subscriptionId":"s1","changeType":"updated","resource":"Users/u1","clientState":"secret",` +
+				`"resourceData":{"@odata.type":"#Microsoft.Graph.User","id":"u1"}},` +
+				`{"subscriptionId":"s1","lifecycleEvent":"reauthorizationRequired","clientState":"secret"}]}`},
+		{name: "invalid clientState", wantCode: http.StatusForbidden,
+			body: `{"value":[{"subscriptionId":"s1","clientState":"secret"},{"subscriptionId":"s1","clientState":"forged"}]}`},
+		{name: "callback error", wantCode: http.StatusInternalServerError, wantChanges: 1,
+			body: `{"value":[{"subscriptionId":"s1","clientState":"secret","resourceData":{"id":"fail"}}]}`},
+		{name: "malformed", wantCode: http.StatusBadRequest, body: `{"value":`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, lifecycles = nil, nil
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantCode || len(changes) != tt.wantChanges || len(lifecycles) != tt.wantLifecycles {
+				t.Errorf("NotificationHandler = %v with %d changes and %d lifecycle notifications, want %v, %d and %d",
+					rec.Code, len(changes), len(lifecycles), tt.wantCode, tt.wantChanges, tt.wantLifecycles)
+			}
+		})
+	}
+}
+
+func TestSubscription_AutoRenew(t *testing.T) {
+	var renewals int32
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			var s Subscription
+			if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+				t.Errorf("cannot decode subscription: %v", err)
+			}
+			s.ID = "s1"
+			json.NewEncoder(w).Encode(s)
+		case http.MethodPatch:
+			if atomic.AddInt32(&renewals, 1) > 2 {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error":{"code":"ResourceNotFound","message":"subscription not found"}}`)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %v %v", r.Method, r.URL)
+		}
+	})
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL)
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+
+	subscription, err := g.CreateSubscription(Subscription{Resource: "users", ChangeType: "updated",
+		NotificationURL: "https://example.com/notify", ExpirationDateTime: time.Now().Add(50 * time.Millisecond)})
+	if err != nil || subscription.ID != "s1" || subscription.Resource != "users" {
+		t.Fatalf("GraphClient.CreateSubscription() = %v, %v", subscription, err)
+	}
+
+	var renewed []Subscription
+	err = subscription.AutoRenew(context.Background(), 100*time.Millisecond, 50*time.Millisecond, func(s Subscription, err error) {
+		if err == nil {
+			renewed = append(renewed, s)
+		}
+	})
+	if !errors.Is(err, ErrNotFound) || len(renewed) != 2 {
+		t.Errorf("Subscription.AutoRenew() error = %v after %d renewals, want ErrNotFound after 2", err, len(renewed))
+	}
+	if len(renewed) == 2 && !renewed[1].ExpirationDateTime.After(renewed[0].ExpirationDateTime) {
+		t.Errorf("Subscription.AutoRenew() did not extend the ExpirationDateTime: %v", renewed)
+	}
+
+	for _, durations := range [][2]time.Duration{{time.Hour, time.Hour}, {time.Minute, time.Hour}, {time.Hour, 0}, {0, -time.Minute}} {
+		if err := subscription.AutoRenew(context.Background(), durations[0], durations[1], nil); err == nil {
+			t.Errorf("Subscription.AutoRenew() with lifetime %v and renewBefore %v error = nil, want error", durations[0], durations[1])
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	subscription.ExpirationDateTime = time.Now().Add(time.Hour)
+	if err := subscription.AutoRenew(ctx, time.Hour, time.Minute, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Subscription.AutoRenew() with cancelled context error = %v, want context.Canceled", err)
+	}
+}
+
+func TestSubscription_MarshalJSON(t *testing.T) {
+	expiration := time.Date(2021, 6, 1, 10, 11, 12, 0, time.UTC)
+	tests := []struct {
+		name         string
+		subscription Subscription
+		want         string
+	}{
+		{
+			name:         "unset expiration is omitted",
+			subscription: Subscription{Resource: "users", ChangeType: "updated"},
+			want:         `{"resource":"users","changeType":"updated"}`,
+		}, {
+			name:         "expiration",
+			subscription: Subscription{Resource: "users", ExpirationDateTime: expiration},
+			want:         `{"resource":"users","expirationDateTime":"2021-06-01T10:11:12Z"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.subscription)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("json.Marshal() = %s, %v, want %s", got, err, tt.want)
+			}
+			var unmarshalled Subscription
+			if err := json.Unmarshal(got, &unmarshalled); err != nil || !unmarshalled.ExpirationDateTime.Equal(tt.subscription.ExpirationDateTime) {
+				t.Errorf("json.Unmarshal() = %v, %v, want ExpirationDateTime %v", unmarshalled.ExpirationDateTime, err, tt.subscription.ExpirationDateTime)
+			}
+		})
+	}
+}
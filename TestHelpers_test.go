@@ -0,0 +1,71 @@
+package msgraph
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SerenityITS-Development/go-msgraph/msgraphtest"
+)
+
+// newTestClient returns a msgraphtest.Server and a GraphClient using it, both are closed when the test
+// finishes
+func newTestClient(t *testing.T, opts ...GraphClientOption) (*msgraphtest.Server, *GraphClient) {
+	t.Helper()
+	srv := msgraphtest.NewServer()
+	t.Cleanup(srv.Close)
+	g, err := NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL, opts...)
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+	return srv, g
+}
+
+// newTestServer returns a httptest.Server for tests that need to inspect or craft the raw requests and
+// responses. Requests to a token endpoint, hence with /oauth2/ in their path, are passed to tokenHandler,
+// all other requests to handler. A nil tokenHandler answers with a valid token test-token, a nil handler
+// with an empty response. The server is closed when the test finishes.
+func newTestServer(t *testing.T, tokenHandler, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case !strings.Contains(r.URL.Path, "/oauth2/"):
+			if handler != nil {
+				handler(w, r)
+			}
+		case tokenHandler != nil:
+			tokenHandler(w, r)
+		default:
+			writeTestToken(w, "test-token", "")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// writeTestToken writes a token response that is valid for an hour, including the refresh token if it is
+// not empty
+func writeTestToken(w http.ResponseWriter, accessToken, refreshToken string) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"token_type":"Bearer","expires_on":"%d","not_before":"%d","resource":"test","access_token":"%v"`,
+		time.Now().Add(time.Hour).Unix(), time.Now().Add(-time.Minute).Unix(), accessToken)
+	if refreshToken != "" {
+		fmt.Fprintf(w, `,"refresh_token":"%v"`, refreshToken)
+	}
+	fmt.Fprint(w, "}")
+}
+
+// countingRoundTripper counts all requests passed through it before handing them to the next http.RoundTripper
+type countingRoundTripper struct {
+	next  http.RoundTripper
+	count int32
+}
+
+func (c *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.count, 1)
+	return c.next.RoundTrip(req)
+}
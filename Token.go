@@ -3,16 +3,20 @@ package msgraph
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // Token struct holds the Microsoft Graph API authentication token used by GraphClient to authenticate API-requests to the ms graph API
 type Token struct {
-	TokenType   string    // should always be "Bearer" for msgraph API-calls
-	NotBefore   time.Time // time when the access token starts to be valid
-	ExpiresOn   time.Time // time when the access token expires
-	Resource    string    // will most likely be https://graph.microsoft.*, hence the Service Root Endpoint
-	AccessToken string    // the access-token itself
+	TokenType    string    // should always be "Bearer" for msgraph API-calls
+	NotBefore    time.Time // time when the access token starts to be valid
+	ExpiresOn    time.Time // time when the access token expires
+	Resource     string    // will most likely be https://graph.microsoft.*, hence the Service Root Endpoint
+	AccessToken  string    // the access-token itself
+	RefreshToken string    // only returned for delegated tokens, e.g. by a RefreshTokenProvider
+	ExtExpiresOn time.Time // extended expiry during an Azure AD outage, equals ExpiresOn if not returned by the token endpoint
 }
 
 func (t Token) String() string {
@@ -63,18 +67,22 @@ func (t Token) WantsToBeRefreshed() bool {
 	return !t.IsValid() || time.Now().After(t.ExpiresOn.Add(-10*time.Second))
 }
 
-// UnmarshalJSON implements the json unmarshal to be used by the json-library.
+// UnmarshalJSON implements the json unmarshal to be used by the json-library. Both the responses of the
+// v1 token endpoint with expires_on and not_before and of the v2.0 endpoint with expires_in and ext_expires_in
+// are supported, the numbers may be sent as JSON numbers or strings.
 //
 // Hint: the UnmarshalJSON also checks immediately if the token is valid, hence
 // the current time.Now() is after NotBefore and before ExpiresOn
 func (t *Token) UnmarshalJSON(data []byte) error {
 	tmp := struct {
-		TokenType   string `json:"token_type"`        // should normally be "Bearer"
-		ExpiresOn   int64  `json:"expires_on,string"` // = UNIX timestamp, parse to int64 immediately
-		NotBefore   int64  `json:"not_before,string"` // = UNIX timestamp, parse to int64 immediately
-		Resource    string `json:"resource"`          // will typically be https://graph.microsoft.com or wherever it came from
-		AccessToken string `json:"access_token"`      // the actual access token - veeery long string
-		//ExpiresIn   string `json:"expires_in"` // not used
+		TokenType    string     `json:"token_type"`     // should normally be "Bearer"
+		ExpiresOn    tokenInt64 `json:"expires_on"`     // = UNIX timestamp, v1 only
+		NotBefore    tokenInt64 `json:"not_before"`     // = UNIX timestamp, v1 only
+		ExpiresIn    tokenInt64 `json:"expires_in"`     // = seconds from now
+		ExtExpiresIn tokenInt64 `json:"ext_expires_in"` // = seconds from now, v2.0 only
+		Resource     string     `json:"resource"`       // will typically be https://graph.microsoft.com or wherever it came from
+		AccessToken  string     `json:"access_token"`   // the actual access token - veeery long string
+		RefreshToken string     `json:"refresh_token"`  // only returned for delegated tokens
 	}{}
 
 	// unmarshal to tmp-struct, return if error
@@ -82,11 +90,24 @@ func (t *Token) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("err on json.Unmarshal: %v | Data: %v", err, string(data))
 	}
 
+	// Hint: the whole seconds of the current time, hence a token issued now is already valid
+	now := time.Unix(time.Now().Unix(), 0)
 	t.TokenType = tmp.TokenType
-	t.ExpiresOn = time.Unix(tmp.ExpiresOn, 0)
-	t.NotBefore = time.Unix(tmp.NotBefore, 0)
+	t.ExpiresOn = time.Unix(int64(tmp.ExpiresOn), 0)
+	if tmp.ExpiresOn == 0 && tmp.ExpiresIn > 0 {
+		t.ExpiresOn = now.Add(time.Duration(tmp.ExpiresIn) * time.Second)
+	}
+	t.NotBefore = time.Unix(int64(tmp.NotBefore), 0)
+	if tmp.NotBefore == 0 {
+		t.NotBefore = now
+	}
+	t.ExtExpiresOn = t.ExpiresOn
+	if tmp.ExtExpiresIn > 0 {
+		t.ExtExpiresOn = now.Add(time.Duration(tmp.ExtExpiresIn) * time.Second)
+	}
 	t.Resource = tmp.Resource
 	t.AccessToken = tmp.AccessToken
+	t.RefreshToken = tmp.RefreshToken
 
 	if t.HasExpired() {
 		return fmt.Errorf("Access-Token ExpiresOn %v is before current system-time %v", t.ExpiresOn, time.Now())
@@ -97,3 +118,21 @@ func (t *Token) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// tokenInt64 is an int64 in a token response, sent either as JSON number or as string
+type tokenInt64 int64
+
+// UnmarshalJSON implements the json unmarshal to be used by the json-library
+func (i *tokenInt64) UnmarshalJSON(data []byte) error {
+	unquoted := strings.Trim(string(data), `"`)
+	if unquoted == "" || unquoted == "null" {
+		*i = 0
+		return nil
+	}
+	value, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot parse %v as integer: %v", string(data), err)
+	}
+	*i = tokenInt64(value)
+	return nil
+}
@@ -0,0 +1,257 @@
+package msgraph
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TokenCache persists Tokens across GraphClient instances and processes, hence short-lived processes, e.g. CLI
+// tools or cron jobs, do not request a new Token on every start. See ClientWithTokenCache and FileTokenCache.
+//
+// The GraphClient only caches the Tokens of the built-in TokenProviders except StaticTokenProvider. Errors of
+// the TokenCache are ignored, the Token is requested from the TokenProvider instead.
+type TokenCache interface {
+	// Load returns the Token stored for the given key, ok is false if there is none. The Token may have expired,
+	// its RefreshToken is used to acquire a new one without an interactive sign-in then.
+	Load(key string) (token Token, ok bool, err error)
+	// Store stores the Token for the given key, replacing the one stored before
+	Store(key string, token Token) error
+}
+
+// tokenCacheKeyer is implemented by the TokenProviders whose Tokens are cached, it returns the identity the
+// Tokens are issued for, e.g. the tenant and application, and a hash of the credential, hence Tokens acquired
+// with a rotated credential are not used anymore
+type tokenCacheKeyer interface {
+	tokenCacheKey() string
+}
+
+// cachedRefreshTokenUser is implemented by the TokenProviders of delegated Tokens. The GraphClient passes them
+// the refresh token loaded from the TokenCache, hence the user does not have to sign in again after a restart.
+type cachedRefreshTokenUser interface {
+	useCachedRefreshToken(refreshToken string)
+}
+
+// tokenCacheKey returns the key of the Tokens of the given TokenProvider in the TokenCache, or an empty string
+// if they are not cached. The key covers the identity, the authentication endpoint and the requested audience.
+func (g *GraphClient) tokenCacheKey(provider TokenProvider) string {
+	keyer, ok := provider.(tokenCacheKeyer)
+	if !ok {
+		return ""
+	}
+	audience := url.Values{}
+	g.setTokenAudience(audience)
+	return fmt.Sprintf("%v|%v|%v|%v", keyer.tokenCacheKey(), g.azureADAuthEndpoint, g.tokenEndpointVersion, audience.Encode())
+}
+
+func (p *ClientSecretProvider) tokenCacheKey() string {
+	return "application|" + p.TenantID + "|" + p.ApplicationID + "|" + credentialHash([]byte(p.ClientSecret))
+}
+
+func (p *CertificateProvider) tokenCacheKey() string {
+	var raw []byte
+	if p.Certificate.Certificate != nil {
+		raw = p.Certificate.Certificate.Raw
+	}
+	return "application|" + p.TenantID + "|" + p.ApplicationID + "|" + credentialHash(raw)
+}
+
+func (p *RefreshTokenProvider) tokenCacheKey() string {
+	return "delegated|" + p.TenantID + "|" + p.ApplicationID + "|" + credentialHash([]byte(p.ClientSecret))
+}
+
+func (p *DeviceCodeProvider) tokenCacheKey() string {
+	return "delegated|" + p.TenantID + "|" + p.ApplicationID
+}
+
+func (p *ManagedIdentityProvider) tokenCacheKey() string {
+	return "managedidentity|" + p.ClientID + "|" + p.ResourceID
+}
+
+// credentialHash returns a truncated SHA-256 hash of the given credential for the key in the TokenCache, the
+// credential itself must never be part of the key
+func credentialHash(credential []byte) string {
+	hash := sha256.Sum256(credential)
+	return hex.EncodeToString(hash[:8])
+}
+
+// useCachedRefreshToken replaces the refresh token, the one of the TokenCache is the most recently rotated one
+func (p *RefreshTokenProvider) useCachedRefreshToken(refreshToken string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.refreshToken = refreshToken
+}
+
+// useCachedRefreshToken sets the refresh token, hence the user does not have to sign in again
+func (p *DeviceCodeProvider) useCachedRefreshToken(refreshToken string) {
+	if p.RefreshToken() != refreshToken {
+		p.SetRefreshToken(refreshToken)
+	}
+}
+
+// FileTokenCache is a TokenCache storing the Tokens AES-GCM encrypted in a single file. The file may be shared
+// by multiple processes, writes are serialized with an exclusive lock on the file path + ".lock".
+//
+// Delegated Tokens are cached per tenant and application, not per user, hence use a separate file per user.
+type FileTokenCache struct {
+	path string
+	aead cipher.AEAD
+	lock sync.Mutex // serializes the access within this process, the file lock serializes the processes
+}
+
+// NewFileTokenCache creates a new FileTokenCache storing the Tokens in the file at path, which is created on the
+// first Store. The encryptionKey must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+func NewFileTokenCache(path string, encryptionKey []byte) (*FileTokenCache, error) {
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &FileTokenCache{path: path, aead: aead}, nil
+}
+
+// fileTokenCacheEntry is the serialized form of a Token in a FileTokenCache
+type fileTokenCacheEntry struct {
+	TokenType    string    `json:"tokenType"`
+	NotBefore    time.Time `json:"notBefore"`
+	ExpiresOn    time.Time `json:"expiresOn"`
+	ExtExpiresOn time.Time `json:"extExpiresOn"`
+	Resource     string    `json:"resource"`
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+}
+
+func (e fileTokenCacheEntry) token() Token {
+	return Token{
+		TokenType:    e.TokenType,
+		NotBefore:    e.NotBefore,
+		ExpiresOn:    e.ExpiresOn,
+		ExtExpiresOn: e.ExtExpiresOn,
+		Resource:     e.Resource,
+		AccessToken:  e.AccessToken,
+		RefreshToken: e.RefreshToken,
+	}
+}
+
+// Load implements TokenCache
+func (c *FileTokenCache) Load(key string) (Token, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	unlock, err := lockFile(c.path + ".lock")
+	if err != nil {
+		return Token{}, false, err
+	}
+	defer unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		return Token{}, false, err
+	}
+	entry, ok := entries[key]
+	if !ok {
+		return Token{}, false, nil
+	}
+	return entry.token(), true, nil
+}
+
+// Store implements TokenCache. Expired Tokens of other keys are removed unless they have a refresh token, a file
+// that cannot be decrypted, e.g. because the encryption key has changed, is replaced.
+func (c *FileTokenCache) Store(key string, token Token) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	unlock, err := lockFile(c.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		entries = map[string]fileTokenCacheEntry{}
+	}
+	for k, entry := range entries {
+		if entry.RefreshToken == "" && entry.token().HasExpired() {
+			delete(entries, k)
+		}
+	}
+	entries[key] = fileTokenCacheEntry{
+		TokenType:    token.TokenType,
+		NotBefore:    token.NotBefore,
+		ExpiresOn:    token.ExpiresOn,
+		ExtExpiresOn: token.ExtExpiresOn,
+		Resource:     token.Resource,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+	}
+	return c.write(entries)
+}
+
+// read reads and decrypts the entries of the file, an empty map is returned if the file does not exist.
+// The caller must hold the file lock.
+func (c *FileTokenCache) read() (map[string]fileTokenCacheEntry, error) {
+	entries := map[string]fileTokenCacheEntry{}
+	data, err := ioutil.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot read token cache: %w", err)
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("cannot decrypt token cache %v: file is too short", c.path)
+	}
+	plaintext, err := c.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt token cache %v: %w", c.path, err)
+	}
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal token cache %v: %w", c.path, err)
+	}
+	return entries, nil
+}
+
+// write encrypts and writes the entries to a temporary file that replaces the file afterwards, hence readers
+// never see a partially written file. The caller must hold the file lock.
+func (c *FileTokenCache) write(entries map[string]fileTokenCacheEntry) error {
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("cannot create nonce: %w", err)
+	}
+	data := c.aead.Seal(nonce, nonce, plaintext, nil)
+
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".tmp*") // Hint: created with mode 0600
+	if err != nil {
+		return fmt.Errorf("cannot write token cache: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // Hint: fails after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot write token cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cannot write token cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("cannot write token cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package msgraph
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// staleLockFileAge is the age after which a lock file is considered to be left over by a crashed process
+var staleLockFileAge = 30 * time.Second
+
+// lockFileExclusive acquires an exclusive lock by creating the file at path, it waits as long as the file exists.
+// It is used where flock is not available. The lock file contains a random owner, lock files older than
+// staleLockFileAge are removed if they still contain the owner read before, see removeLockFile. The lock is
+// released by the returned unlock func.
+func lockFileExclusive(path string) (unlock func() error, err error) {
+	owner, err := newLockFileOwner()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, err = f.WriteString(owner)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("cannot lock %v: %w", path, err)
+			}
+			return func() error {
+				if !removeLockFile(path, owner) {
+					return fmt.Errorf("cannot unlock %v: the lock has been taken over as stale", path)
+				}
+				return nil
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("cannot lock %v: %w", path, err)
+		}
+		// Hint: the owner is read before the age, hence a stale owner is never paired with the age of a new lock
+		staleOwner, err := ioutil.ReadFile(path)
+		if err == nil {
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockFileAge {
+				removeLockFile(path, string(staleOwner))
+				continue
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// removeLockFile removes the lock file at path if it contains the given owner and returns true. The file is
+// atomically renamed to a unique name before its owner is checked, hence a lock file that another process has
+// created in the meantime is never removed. It is restored instead and false is returned.
+func removeLockFile(path, owner string) bool {
+	suffix, err := newLockFileOwner()
+	if err != nil {
+		return false
+	}
+	removed := path + "." + suffix
+	if err := os.Rename(path, removed); err != nil {
+		return false
+	}
+	defer os.Remove(removed)
+	if data, err := ioutil.ReadFile(removed); err == nil && string(data) == owner {
+		return true
+	}
+	// Hint: a link fails if the path exists, in contrast to a rename it never replaces a lock file
+	os.Link(removed, path)
+	return false
+}
+
+// newLockFileOwner returns a random owner of a lock file
+func newLockFileOwner() (string, error) {
+	owner := make([]byte, 16)
+	if _, err := rand.Read(owner); err != nil {
+		return "", fmt.Errorf("cannot create lock file owner: %w", err)
+	}
+	return hex.EncodeToString(owner), nil
+}
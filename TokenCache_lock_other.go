@@ -0,0 +1,9 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package msgraph
+
+// lockFile acquires an exclusive lock on the file at path, see lockFileExclusive
+func lockFile(path string) (unlock func() error, err error) {
+	return lockFileExclusive(path)
+}
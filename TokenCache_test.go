@@ -0,0 +1,249 @@
+package msgraph
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestFileTokenCache(t *testing.T, path string, key string) *FileTokenCache {
+	t.Helper()
+	cache, err := NewFileTokenCache(path, []byte(key))
+	if err != nil {
+		t.Fatalf("NewFileTokenCache() error = %v", err)
+	}
+	return cache
+}
+
+func TestFileTokenCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	cache := newTestFileTokenCache(t, path, "0123456789abcdef0123456789abcdef")
+
+	if _, ok, err := cache.Load("missing"); ok || err != nil {
+		t.Errorf("FileTokenCache.Load() on missing file = %v, %v, want false, nil", ok, err)
+	}
+	token := Token{TokenType: "Bearer", AccessToken: "secret-access-token", RefreshToken: "secret-refresh-token",
+		NotBefore: time.Now().Add(-time.Minute).Truncate(time.Second), ExpiresOn: time.Now().Add(time.Hour).Truncate(time.Second)}
+	token.ExtExpiresOn = token.ExpiresOn
+	if err := cache.Store("key", token); err != nil {
+		t.Fatalf("FileTokenCache.Store() error = %v", err)
+	}
+	expired := Token{AccessToken: "expired", NotBefore: time.Now().Add(-time.Hour), ExpiresOn: time.Now().Add(-time.Minute)}
+	if err := cache.Store("expired", expired); err != nil {
+		t.Fatalf("FileTokenCache.Store() error = %v", err)
+	}
+
+	got, ok, err := cache.Load("key")
+	if err != nil || !ok {
+		t.Fatalf("FileTokenCache.Load() = %v, %v, want true, nil", ok, err)
+	}
+	if got.AccessToken != token.AccessToken || got.RefreshToken != token.RefreshToken || !got.ExpiresOn.Equal(token.ExpiresOn) || !got.NotBefore.Equal(token.NotBefore) {
+		t.Errorf("FileTokenCache.Load() = %v, want %v", got, token)
+	}
+	// Hint: an expired Token is loaded for its refresh token, it is removed by the next Store without one
+	if got, ok, _ := cache.Load("expired"); !ok || !got.HasExpired() {
+		t.Errorf("FileTokenCache.Load() of expired Token = %v, %v, want the expired Token", got, ok)
+	}
+	if err := cache.Store("key", token); err != nil {
+		t.Fatalf("FileTokenCache.Store() error = %v", err)
+	}
+	if _, ok, _ := cache.Load("expired"); ok {
+		t.Errorf("FileTokenCache.Load() of expired Token after Store ok = true, want false")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read cache file: %v", err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("cache file contains plaintext Token")
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("cache file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	otherKey := newTestFileTokenCache(t, path, "fedcba9876543210")
+	if _, _, err := otherKey.Load("key"); err == nil {
+		t.Errorf("FileTokenCache.Load() with other encryption key error = nil, want error")
+	}
+	if err := otherKey.Store("key", token); err != nil {
+		t.Errorf("FileTokenCache.Store() with other encryption key error = %v, want replaced file", err)
+	}
+
+	if _, err := NewFileTokenCache(path, []byte("short")); err == nil {
+		t.Errorf("NewFileTokenCache() with invalid key length error = nil, want error")
+	}
+}
+
+func TestFileTokenCache_concurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	token := Token{TokenType: "Bearer", AccessToken: "token", NotBefore: time.Now().Add(-time.Minute), ExpiresOn: time.Now().Add(time.Hour)}
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Hint: separate instances, hence only the file lock serializes them like separate processes
+			cache := newTestFileTokenCache(t, path, "0123456789abcdef")
+			if err := cache.Store(fmt.Sprintf("key-%d", i), token); err != nil {
+				t.Errorf("FileTokenCache.Store() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	cache := newTestFileTokenCache(t, path, "0123456789abcdef")
+	for i := 0; i < writers; i++ {
+		if _, ok, err := cache.Load(fmt.Sprintf("key-%d", i)); !ok || err != nil {
+			t.Errorf("FileTokenCache.Load(key-%d) = %v, %v, want true, nil", i, ok, err)
+		}
+	}
+}
+
+func TestGraphClient_tokenCache(t *testing.T) {
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1"}`)
+	})
+	requests := &countingRoundTripper{next: http.DefaultTransport}
+	path := filepath.Join(t.TempDir(), "tokens")
+	newClient := func(applicationID, clientSecret string) *GraphClient {
+		t.Helper()
+		g, err := NewGraphClientWithTokenProvider(NewClientSecretProvider("tenant", applicationID, clientSecret), ClientWithEndpoints(srv.URL, srv.URL),
+			ClientWithRoundTripper(requests), ClientWithTokenCache(newTestFileTokenCache(t, path, "0123456789abcdef")))
+		if err != nil {
+			t.Fatalf("NewGraphClientWithTokenProvider() error = %v", err)
+		}
+		return g
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := newClient("app", "secret").GetUser("1"); err != nil {
+			t.Errorf("GraphClient.GetUser() error = %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests.count); got != 4 {
+		t.Errorf("requests = %v, want 4, hence 1 token request and 3 API-calls", got)
+	}
+
+	newClient("other-app", "secret")
+	if got := atomic.LoadInt32(&requests.count); got != 5 {
+		t.Errorf("requests = %v, want 5, another application must not use the cached Token", got)
+	}
+	newClient("app", "rotated-secret")
+	if got := atomic.LoadInt32(&requests.count); got != 6 {
+		t.Errorf("requests = %v, want 6, a rotated client secret must not use the cached Token", got)
+	}
+}
+
+func TestGraphClient_tokenCacheRefreshToken(t *testing.T) {
+	var grants []string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/devicecode"):
+			fmt.Fprint(w, `{"device_code":"dc","user_code":"ABC-123","verification_uri":"https://microsoft.com/devicelogin","expires_in":900,"interval":1}`)
+		case r.PostForm.Get("grant_type") == "refresh_token":
+			grants = append(grants, "refresh_token "+r.PostForm.Get("refresh_token"))
+			writeTestToken(w, "refreshed", r.PostForm.Get("refresh_token")+"-2")
+		default:
+			grants = append(grants, "device_code")
+			// Hint: the access token expires right away, hence the restarted client has to refresh it
+			fmt.Fprint(w, `{"token_type":"Bearer","expires_in":5,"access_token":"signed-in","refresh_token":"rt"}`)
+		}
+	}, nil)
+	defer func(unit time.Duration) { deviceCodeIntervalUnit = unit }(deviceCodeIntervalUnit)
+	deviceCodeIntervalUnit = time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "tokens")
+	var signIns int
+	newClient := func() *DeviceCodeProvider {
+		t.Helper()
+		provider := NewDeviceCodeProvider("organizations", "app", func(code DeviceCode) { signIns++ })
+		_, err := NewGraphClientWithTokenProvider(provider, ClientWithEndpoints(srv.URL, srv.URL),
+			ClientWithTokenCache(newTestFileTokenCache(t, path, "0123456789abcdef")))
+		if err != nil {
+			t.Fatalf("NewGraphClientWithTokenProvider() error = %v", err)
+		}
+		return provider
+	}
+
+	newClient()
+	restarted := newClient()
+	if signIns != 1 || fmt.Sprint(grants) != "[device_code refresh_token rt]" {
+		t.Errorf("restarted client: %d sign-ins with grants %v, want 1 sign-in and the cached refresh token", signIns, grants)
+	}
+	if restarted.RefreshToken() != "rt-2" {
+		t.Errorf("DeviceCodeProvider.RefreshToken() = %v, want the rotated rt-2", restarted.RefreshToken())
+	}
+
+	// the cached access token is valid, the cached refresh token is passed to the provider anyway
+	if again := newClient(); again.RefreshToken() != "rt-2" || len(grants) != 2 {
+		t.Errorf("DeviceCodeProvider.RefreshToken() = %v after %v, want rt-2 without further requests", again.RefreshToken(), grants)
+	}
+}
+
+func TestLockFileExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.lock")
+
+	var counter, maxCounter int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := lockFileExclusive(path)
+			if err != nil {
+				t.Errorf("lockFileExclusive() error = %v", err)
+				return
+			}
+			if n := atomic.AddInt32(&counter, 1); n > atomic.LoadInt32(&maxCounter) {
+				atomic.StoreInt32(&maxCounter, n)
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&counter, -1)
+			if err := unlock(); err != nil {
+				t.Errorf("unlock() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if maxCounter != 1 {
+		t.Errorf("lockFileExclusive() allowed %d concurrent holders, want 1", maxCounter)
+	}
+
+	// a lock file of another owner is never removed, it is restored
+	if err := ioutil.WriteFile(path, []byte("other"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if removeLockFile(path, "crashed") {
+		t.Errorf("removeLockFile() of another owner = true, want false")
+	}
+	if data, err := ioutil.ReadFile(path); err != nil || string(data) != "other" {
+		t.Errorf("lock file = %q, %v after removeLockFile() of another owner, want it restored", data, err)
+	}
+
+	// a stale lock file is taken over
+	old := time.Now().Add(-2 * staleLockFileAge)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := lockFileExclusive(path)
+	if err != nil {
+		t.Fatalf("lockFileExclusive() with stale lock file error = %v", err)
+	}
+	if err := unlock(); err != nil {
+		t.Errorf("unlock() error = %v", err)
+	}
+	if matches, _ := filepath.Glob(path + "*"); len(matches) != 0 {
+		t.Errorf("lock files left after unlock: %v", matches)
+	}
+}
@@ -0,0 +1,259 @@
+package msgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// TokenProvider acquires the Tokens used by a GraphClient to authenticate API-calls. The GraphClient
+// keeps the Token until it WantsToBeRefreshed and only then asks the TokenProvider for a new one,
+// concurrent API-calls share a single refresh.
+//
+// Built-in implementations are ClientSecretProvider, CertificateProvider, StaticTokenProvider and
+// RefreshTokenProvider. Use NewGraphClientWithTokenProvider to create a GraphClient with any TokenProvider.
+type TokenProvider interface {
+	// Token returns a new Token, ctx is the context of the API-call that triggered the refresh
+	Token(ctx context.Context) (Token, error)
+}
+
+// graphClientTokenProvider is implemented by the built-in TokenProviders that request the Token from the
+// Azure AD authentication endpoint of the GraphClient, hence they use its endpoints and http.Client.
+type graphClientTokenProvider interface {
+	TokenProvider
+	setGraphClient(g *GraphClient)
+}
+
+// errTokenProviderNotGraphClientSourced is returned by a built-in TokenProvider that is not used by a GraphClient
+var errTokenProviderNotGraphClientSourced = fmt.Errorf("token provider is not used by a GraphClient, see NewGraphClientWithTokenProvider")
+
+// ClientSecretProvider acquires application Tokens with the client credentials grant and a client secret
+type ClientSecretProvider struct {
+	TenantID      string
+	ApplicationID string
+	ClientSecret  string
+
+	graphClient *GraphClient // the graphClient that uses this provider
+}
+
+// NewClientSecretProvider creates a new ClientSecretProvider, this is the default of NewGraphClient
+func NewClientSecretProvider(tenantID, applicationID, clientSecret string) *ClientSecretProvider {
+	return &ClientSecretProvider{TenantID: tenantID, ApplicationID: applicationID, ClientSecret: clientSecret}
+}
+
+// setGraphClient sets the graphClient instance that uses this provider
+func (p *ClientSecretProvider) setGraphClient(g *GraphClient) {
+	p.graphClient = g
+}
+
+// Token implements TokenProvider
+func (p *ClientSecretProvider) Token(ctx context.Context) (Token, error) {
+	if p.graphClient == nil {
+		return Token{}, errTokenProviderNotGraphClientSourced
+	}
+	data := url.Values{}
+	data.Add("grant_type", "client_credentials")
+	data.Add("client_id", p.ApplicationID)
+	data.Add("client_secret", p.ClientSecret)
+	return p.graphClient.requestToken(ctx, p.TenantID, data)
+}
+
+// CertificateProvider acquires application Tokens with the client credentials grant and a client assertion
+// signed with a ClientCertificate
+type CertificateProvider struct {
+	TenantID      string
+	ApplicationID string
+	Certificate   ClientCertificate
+
+	graphClient *GraphClient // the graphClient that uses this provider
+}
+
+// NewCertificateProvider creates a new CertificateProvider, this is the default of NewGraphClientWithCertificate
+func NewCertificateProvider(tenantID, applicationID string, certificate ClientCertificate) *CertificateProvider {
+	return &CertificateProvider{TenantID: tenantID, ApplicationID: applicationID, Certificate: certificate}
+}
+
+// setGraphClient sets the graphClient instance that uses this provider
+func (p *CertificateProvider) setGraphClient(g *GraphClient) {
+	p.graphClient = g
+}
+
+// Token implements TokenProvider
+func (p *CertificateProvider) Token(ctx context.Context) (Token, error) {
+	if p.graphClient == nil {
+		return Token{}, errTokenProviderNotGraphClientSourced
+	}
+	tokenEndpoint, err := p.graphClient.tokenEndpoint(p.TenantID)
+	if err != nil {
+		return Token{}, err
+	}
+	assertion, err := p.Certificate.clientAssertion(tokenEndpoint, p.ApplicationID)
+	if err != nil {
+		return Token{}, fmt.Errorf("cannot create client assertion: %w", err)
+	}
+	data := url.Values{}
+	data.Add("grant_type", "client_credentials")
+	data.Add("client_id", p.ApplicationID)
+	data.Add("client_assertion_type", clientAssertionType)
+	data.Add("client_assertion", assertion)
+	return p.graphClient.requestToken(ctx, p.TenantID, data)
+}
+
+// StaticTokenProvider always provides the same, pre-acquired Token, e.g. a Token acquired by another
+// library. Once the Token has expired, every API-call fails.
+type StaticTokenProvider struct {
+	token Token
+}
+
+// NewStaticTokenProvider creates a new StaticTokenProvider for the given Token
+func NewStaticTokenProvider(token Token) *StaticTokenProvider {
+	return &StaticTokenProvider{token: token}
+}
+
+// Token implements TokenProvider, returns an error if the Token is not valid (anymore)
+func (p *StaticTokenProvider) Token(ctx context.Context) (Token, error) {
+	if !p.token.IsValid() {
+		return Token{}, fmt.Errorf("static Token is not valid between %v and %v", p.token.NotBefore, p.token.ExpiresOn)
+	}
+	return p.token, nil
+}
+
+// RefreshTokenProvider acquires delegated Tokens with the refresh token grant, hence the API-calls are made
+// on behalf of the signed-in user. The refresh token is obtained by an interactive sign-in of the user, e.g.
+// with the authorization code flow. The ms graph API rotates the refresh token with every Token, the current
+// one is available with RefreshToken and passed to OnRefreshTokenChanged to persist it.
+type RefreshTokenProvider struct {
+	TenantID      string
+	ApplicationID string
+	ClientSecret  string // required for confidential clients only, leave empty for public clients
+	// OnRefreshTokenChanged is called with the new refresh token whenever it has been rotated, may be nil
+	OnRefreshTokenChanged func(refreshToken string)
+
+	lock         sync.Mutex   // protects refreshToken and graphClient
+	refreshToken string       // the current refresh token
+	graphClient  *GraphClient // the graphClient that uses this provider
+}
+
+// NewRefreshTokenProvider creates a new RefreshTokenProvider starting with the given refresh token
+func NewRefreshTokenProvider(tenantID, applicationID, refreshToken string) *RefreshTokenProvider {
+	return &RefreshTokenProvider{TenantID: tenantID, ApplicationID: applicationID, refreshToken: refreshToken}
+}
+
+// setGraphClient sets the graphClient instance that uses this provider
+func (p *RefreshTokenProvider) setGraphClient(g *GraphClient) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.graphClient = g
+}
+
+// RefreshToken returns the current refresh token
+func (p *RefreshTokenProvider) RefreshToken() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.refreshToken
+}
+
+// Token implements TokenProvider
+func (p *RefreshTokenProvider) Token(ctx context.Context) (Token, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.graphClient == nil {
+		return Token{}, errTokenProviderNotGraphClientSourced
+	}
+
+	data := url.Values{}
+	data.Add("grant_type", "refresh_token")
+	data.Add("client_id", p.ApplicationID)
+	data.Add("refresh_token", p.refreshToken)
+	if p.ClientSecret != "" {
+		data.Add("client_secret", p.ClientSecret)
+	}
+	token, err := p.graphClient.requestToken(ctx, p.TenantID, data)
+	if err != nil {
+		return Token{}, err
+	}
+	if token.RefreshToken != "" && token.RefreshToken != p.refreshToken {
+		p.refreshToken = token.RefreshToken
+		if p.OnRefreshTokenChanged != nil {
+			p.OnRefreshTokenChanged(token.RefreshToken)
+		}
+	}
+	return token, nil
+}
+
+// TokenEndpointVersion selects the version of the Azure AD token endpoint, see ClientWithTokenEndpointVersion
+type TokenEndpointVersion string
+
+const (
+	// TokenEndpointV2 is the /oauth2/v2.0/token endpoint requesting scopes, this is the default
+	TokenEndpointV2 TokenEndpointVersion = "v2.0"
+	// TokenEndpointV1 is the legacy /oauth2/token endpoint requesting the service root endpoint as resource
+	TokenEndpointV1 TokenEndpointVersion = "v1"
+)
+
+// tokenEndpoint returns the URL of the token endpoint of the given tenant at the Azure AD authentication endpoint
+func (g *GraphClient) tokenEndpoint(tenantID string) (string, error) {
+	return g.oauth2Endpoint(tenantID, "token")
+}
+
+// oauth2Endpoint returns the URL of the given OAuth2 endpoint, e.g. token or devicecode, of the given tenant at
+// the Azure AD authentication endpoint in the version selected with ClientWithTokenEndpointVersion
+func (g *GraphClient) oauth2Endpoint(tenantID, endpoint string) (string, error) {
+	if tenantID == "" {
+		return "", fmt.Errorf("tenant ID is empty")
+	}
+	u, err := url.ParseRequestURI(g.azureADAuthEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse URI: %v", err)
+	}
+	if g.tokenEndpointVersion == TokenEndpointV1 {
+		u.Path = fmt.Sprintf("/%v/oauth2/%v", tenantID, endpoint)
+	} else {
+		u.Path = fmt.Sprintf("/%v/oauth2/v2.0/%v", tenantID, endpoint)
+	}
+	return u.String(), nil
+}
+
+// setTokenAudience sets the scope, respectively the resource for TokenEndpointV1, of a request to an OAuth2
+// endpoint. The scopes of ClientWithScopes are used, by default the service root endpoint with /.default.
+func (g *GraphClient) setTokenAudience(data url.Values) {
+	if g.tokenEndpointVersion == TokenEndpointV1 {
+		data.Set("resource", g.serviceRootEndpoint)
+	} else if len(g.scopes) > 0 {
+		data.Set("scope", strings.Join(g.scopes, " "))
+	} else {
+		data.Set("scope", strings.TrimSuffix(g.serviceRootEndpoint, "/")+"/.default")
+	}
+}
+
+// requestToken requests a Token from the token endpoint of the given tenant with the given form data, which must
+// contain the grant_type and the client credentials. The scope respectively resource is set by setTokenAudience.
+func (g *GraphClient) requestToken(ctx context.Context, tenantID string, data url.Values) (Token, error) {
+	tokenEndpoint, err := g.tokenEndpoint(tenantID)
+	if err != nil {
+		return Token{}, err
+	}
+	g.setTokenAudience(data)
+	var token Token
+	err = g.postOAuth2Form(ctx, tokenEndpoint, data, &token)
+	return token, err
+}
+
+// postOAuth2Form posts the given form data to the given OAuth2 endpoint and json-unmarshals the response into v
+func (g *GraphClient) postOAuth2Form(ctx context.Context, endpoint string, data url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("HTTP Request Error: %v", err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	_, body, err := g.doRequest(req) // retries according to the RetryPolicy, returns a GraphError if the StatusCode is not OK
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
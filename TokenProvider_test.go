@@ -0,0 +1,127 @@
+package msgraph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenProvider(t *testing.T) {
+	var rotations int
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("refresh_token") != "refresh-0" || r.PostForm.Get("client_secret") != "" {
+			t.Errorf("token request = %v, want refresh_token grant with refresh-0 and without client_secret", r.PostForm)
+		}
+		rotations++
+		writeTestToken(w, fmt.Sprintf("delegated-%d", rotations), fmt.Sprintf("refresh-%d", rotations))
+	}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer delegated-1" {
+			t.Errorf("Authorization header = %v, want Bearer delegated-1", r.Header.Get("Authorization"))
+		}
+		fmt.Fprint(w, `{"id":"me"}`)
+	})
+
+	provider := NewRefreshTokenProvider("tenant", "app", "refresh-0")
+	var persisted string
+	provider.OnRefreshTokenChanged = func(refreshToken string) { persisted = refreshToken }
+	g, err := NewGraphClientWithTokenProvider(provider, ClientWithEndpoints(srv.URL, srv.URL))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithTokenProvider() error = %v", err)
+	}
+	if _, err := g.GetUser("me"); err != nil {
+		t.Errorf("GraphClient.GetUser() error = %v", err)
+	}
+	if provider.RefreshToken() != "refresh-1" || persisted != "refresh-1" {
+		t.Errorf("RefreshTokenProvider.RefreshToken() = %v, persisted %v, want refresh-1", provider.RefreshToken(), persisted)
+	}
+}
+
+func TestStaticTokenProvider(t *testing.T) {
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer static" {
+			t.Errorf("Authorization header = %v, want Bearer static", r.Header.Get("Authorization"))
+		}
+		fmt.Fprint(w, `{"id":"1"}`)
+	})
+	token := Token{TokenType: "Bearer", AccessToken: "static", NotBefore: time.Now().Add(-time.Minute), ExpiresOn: time.Now().Add(time.Hour)}
+	g, err := NewGraphClientWithTokenProvider(NewStaticTokenProvider(token), ClientWithEndpoints(srv.URL, srv.URL))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithTokenProvider() error = %v", err)
+	}
+	if _, err := g.GetUser("1"); err != nil {
+		t.Errorf("GraphClient.GetUser() error = %v", err)
+	}
+
+	token.ExpiresOn = time.Now().Add(-time.Second)
+	if _, err := NewGraphClientWithTokenProvider(NewStaticTokenProvider(token)); err == nil {
+		t.Errorf("NewGraphClientWithTokenProvider() with expired static Token error = nil, want error")
+	}
+}
+
+// tokenProviderFunc implements TokenProvider with a func
+type tokenProviderFunc func(ctx context.Context) (Token, error)
+
+func (f tokenProviderFunc) Token(ctx context.Context) (Token, error) {
+	return f(ctx)
+}
+
+func TestGraphClient_customTokenProvider(t *testing.T) {
+	srv := newTestServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1"}`)
+	})
+	var calls int
+	provider := tokenProviderFunc(func(ctx context.Context) (Token, error) {
+		calls++
+		// expires within the refresh margin, hence every API-call refreshes it
+		return Token{TokenType: "Bearer", AccessToken: "custom", NotBefore: time.Now().Add(-time.Minute), ExpiresOn: time.Now().Add(5 * time.Second)}, nil
+	})
+	g, err := NewGraphClientWithTokenProvider(provider, ClientWithEndpoints(srv.URL, srv.URL))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithTokenProvider() error = %v", err)
+	}
+	if _, err := g.GetUser("1"); err != nil {
+		t.Errorf("GraphClient.GetUser() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("TokenProvider.Token() has been called %d times, want 2", calls)
+	}
+}
+
+func TestGraphClient_tokenEndpointVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []GraphClientOption
+		wantPath  string
+		wantScope string
+		wantRes   bool
+	}{
+		{name: "default v2.0", wantPath: "/tenant/oauth2/v2.0/token", wantScope: "{{srv}}/.default"},
+		{name: "custom scopes", opts: []GraphClientOption{ClientWithScopes("User.Read", "offline_access")},
+			wantPath: "/tenant/oauth2/v2.0/token", wantScope: "User.Read offline_access"},
+		{name: "v1", opts: []GraphClientOption{ClientWithTokenEndpointVersion(TokenEndpointV1)},
+			wantPath: "/tenant/oauth2/token", wantRes: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var srvURL string
+			srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				wantScope := strings.Replace(tt.wantScope, "{{srv}}", srvURL, 1)
+				if r.URL.Path != tt.wantPath || r.PostForm.Get("scope") != wantScope || (r.PostForm.Get("resource") == srvURL) != tt.wantRes {
+					t.Errorf("token request %v with %v, want %v with scope %q", r.URL.Path, r.PostForm, tt.wantPath, wantScope)
+				}
+				writeTestToken(w, "test-token", "")
+			}, nil)
+			srvURL = srv.URL
+
+			opts := append([]GraphClientOption{ClientWithEndpoints(srv.URL, srv.URL)}, tt.opts...)
+			if _, err := NewGraphClientWithTokenProvider(NewClientSecretProvider("tenant", "app", "secret"), opts...); err != nil {
+				t.Errorf("NewGraphClientWithTokenProvider() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package msgraph
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestToken_UnmarshalJSON(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name             string
+		data             string
+		wantExpiresOn    time.Time
+		wantExtExpiresOn time.Time
+		wantErr          bool
+	}{
+		{
+			name:             "v1 with strings",
+			data:             `{"token_type":"Bearer","expires_on":"` + strconv.FormatInt(now.Add(time.Hour).Unix(), 10) + `","not_before":"` + strconv.FormatInt(now.Add(-time.Minute).Unix(), 10) + `","expires_in":"3599","access_token":"a"}`,
+			wantExpiresOn:    time.Unix(now.Add(time.Hour).Unix(), 0),
+			wantExtExpiresOn: time.Unix(now.Add(time.Hour).Unix(), 0),
+		}, {
+			name:             "v2.0 with numbers",
+			data:             `{"token_type":"Bearer","expires_in":3599,"ext_expires_in":7199,"access_token":"a"}`,
+			wantExpiresOn:    now.Add(3599 * time.Second),
+			wantExtExpiresOn: now.Add(7199 * time.Second),
+		}, {
+			name:    "expired",
+			data:    `{"token_type":"Bearer","expires_on":"` + strconv.FormatInt(now.Add(-time.Minute).Unix(), 10) + `","access_token":"a"}`,
+			wantErr: true,
+		}, {
+			name:    "invalid number",
+			data:    `{"token_type":"Bearer","expires_in":"soon","access_token":"a"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token Token
+			err := json.Unmarshal([]byte(tt.data), &token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Token.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := token.ExpiresOn.Sub(tt.wantExpiresOn); diff < -time.Second || diff > time.Second {
+				t.Errorf("Token.ExpiresOn = %v, want %v", token.ExpiresOn, tt.wantExpiresOn)
+			}
+			if diff := token.ExtExpiresOn.Sub(tt.wantExtExpiresOn); diff < -time.Second || diff > time.Second {
+				t.Errorf("Token.ExtExpiresOn = %v, want %v", token.ExtExpiresOn, tt.wantExtExpiresOn)
+			}
+			if !token.IsValid() || token.WantsToBeRefreshed() {
+				t.Errorf("Token %v is not valid", token)
+			}
+		})
+	}
+}
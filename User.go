@@ -1,7 +1,6 @@
 package msgraph
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -25,6 +24,7 @@ type User struct {
 	Department        string            `json:"department,omitempty"`
 	MailNickname      string            `json:"mailNickname,omitempty"`
 	PasswordProfile   PasswordProfile   `json:"passwordProfile,omitempty"`
+	SignInActivity    *SignInActivity   `json:"signInActivity,omitempty"` // only returned if selected with $select, nil otherwise
 
 	activePhone string       // private cache for the active phone number
 	graphClient *GraphClient // the graphClient that called the user
@@ -35,6 +35,19 @@ type AssignedLicense struct {
 	SkuID         string   `json:"skuId,omitempty"`
 }
 
+// SignInActivity holds the last sign-ins of a User, the beta API version additionally returns the last
+// successful sign-in. Requires the AuditLog.Read.All permission.
+//
+// See https://docs.microsoft.com/en-us/graph/api/resources/signinactivity
+type SignInActivity struct {
+	LastSignInDateTime                *time.Time `json:"lastSignInDateTime,omitempty"` // nil if the User never signed in
+	LastSignInRequestID               string     `json:"lastSignInRequestId,omitempty"`
+	LastNonInteractiveSignInDateTime  *time.Time `json:"lastNonInteractiveSignInDateTime,omitempty"`
+	LastNonInteractiveSignInRequestID string     `json:"lastNonInteractiveSignInRequestId,omitempty"`
+	LastSuccessfulSignInDateTime      *time.Time `json:"lastSuccessfulSignInDateTime,omitempty"`  // beta only
+	LastSuccessfulSignInRequestID     string     `json:"lastSuccessfulSignInRequestId,omitempty"` // beta only
+}
+
 type PasswordProfile struct {
 	ForceChangePasswordNextSignIn        bool   `json:"forceChangePasswordNextSignIn,omitempty"`
 	ForceChangePasswordNextSignInWithMfa bool   `json:"forceChangePasswordNextSignInWithMfa,omitempty"`
@@ -59,18 +72,8 @@ func (u *User) setGraphClient(gC *GraphClient) {
 //
 // Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user-list-calendargroups
 func (u User) ListCalendarGroups(opts ...ListQueryOption) (CalendarGroups, error) {
-	if u.graphClient == nil {
-		return CalendarGroups{}, ErrNotGraphClientSourced
-	}
-	resource := fmt.Sprintf("/users/%v/calendarGroups", u.ID)
-
-	var marsh struct {
-		CalendarGroups CalendarGroups `json:"value"`
-	}
-	err := u.graphClient.makeGETAPICall(resource, compileListQueryOptions(opts), &marsh)
-
-	marsh.CalendarGroups.setGraphClient(u.graphClient, &u)
-	return marsh.CalendarGroups, err
+	calendarGroups, err := NewCollection[CalendarGroup](u.graphClient, fmt.Sprintf("/users/%v/calendarGroups", u.ID)).List(opts...)
+	return CalendarGroups(calendarGroups).setGraphClient(u.graphClient, &u), err
 }
 
 // CreateCalendarGroup returns all calendar groups associated to that user.
@@ -78,26 +81,10 @@ func (u User) ListCalendarGroups(opts ...ListQueryOption) (CalendarGroups, error
 //
 // Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user-list-calendargroups
 func (u User) CreateCalendarGroup(name string, opts ...CreateQueryOption) (CalendarGroup, error) {
-
-	if u.graphClient == nil {
-		return CalendarGroup{}, ErrNotGraphClientSourced
-	}
-
-	resource := fmt.Sprintf("/users/%v/calendarGroups", u.ID)
-	calendarGroup := CalendarGroup{graphClient: u.graphClient, user: &u}
-	bodyBytes, err := json.Marshal(struct {
+	calendarGroup, err := NewCollection[CalendarGroup](u.graphClient, fmt.Sprintf("/users/%v/calendarGroups", u.ID)).Create(struct {
 		Name string `json:"name"`
-	}{Name: name})
-	if err != nil {
-		return CalendarGroup{}, err
-	}
-	if err != nil {
-		return calendarGroup, err
-	}
-
-	reader := bytes.NewReader(bodyBytes)
-	err = u.graphClient.makePOSTAPICall(resource, compileCreateQueryOptions(opts), reader, &calendarGroup)
-	calendarGroup.setGraphClient(u.graphClient)
+	}{Name: name}, opts...)
+	calendarGroup.user = &u
 	return calendarGroup, err
 }
 
@@ -106,17 +93,7 @@ func (u User) CreateCalendarGroup(name string, opts ...CreateQueryOption) (Calen
 //
 // Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user_list_calendars
 func (u User) ListCalendars(opts ...ListQueryOption) (Calendars, error) {
-	if u.graphClient == nil {
-		return Calendars{}, ErrNotGraphClientSourced
-	}
-	resource := fmt.Sprintf("/users/%v/calendars", u.ID)
-
-	var marsh struct {
-		Calendars Calendars `json:"value"`
-	}
-	err := u.graphClient.makeGETAPICall(resource, compileListQueryOptions(opts), &marsh)
-	marsh.Calendars.setGraphClient(u.graphClient)
-	return marsh.Calendars, err
+	return NewCollection[Calendar](u.graphClient, fmt.Sprintf("/users/%v/calendars", u.ID)).List(opts...)
 }
 
 // ListCalendarView returns the CalendarEvents of the given user within the specified
@@ -131,14 +108,10 @@ func (u User) ListCalendarView(startDateTime, endDateTime time.Time, opts ...Lis
 		return CalendarEvents{}, ErrNotGraphClientSourced
 	}
 
-	if len(globalSupportedTimeZones.Value) == 0 {
-		var err error
-		// TODO: this is a dirty fix, because opts could contain other things than a context, e.g. select
-		// parameters. This could produce unexpected outputs and therefore break the globalSupportedTimeZones variable.
-		globalSupportedTimeZones, err = u.getTimeZoneChoices(compileListQueryOptions(opts))
-		if err != nil {
-			return CalendarEvents{}, err
-		}
+	// TODO: this is a dirty fix, because opts could contain other things than a context, e.g. select
+	// parameters. This could produce unexpected outputs and therefore break the globalSupportedTimeZones variable.
+	if err := loadGlobalSupportedTimeZones(u, compileListQueryOptions(opts)); err != nil {
+		return CalendarEvents{}, err
 	}
 
 	resource := fmt.Sprintf("/users/%v/calendar/calendarview", u.ID)
@@ -157,6 +130,81 @@ func (u User) ListCalendarView(startDateTime, endDateTime time.Time, opts ...Lis
 	return newEvents, nil
 }
 
+// IterateCalendarView returns a CalendarEventsIterator to load the CalendarEvents of the user's default
+// calendar within the specified start- and endDateTime page by page, instead of loading all of them at once
+// like ListCalendarView. Use ListWithPageSize to set the page size and ListWithNextLink to resume.
+// Returns an error if the user it not GraphClient sourced or if the supported time zones cannot be loaded.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+//
+// See https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user_list_calendarview
+func (u User) IterateCalendarView(startDateTime, endDateTime time.Time, opts ...ListQueryOption) (*CalendarEventsIterator, error) {
+	if u.graphClient == nil {
+		return nil, ErrNotGraphClientSourced
+	}
+
+	// TODO: this is a dirty fix, because opts could contain other things than a context, e.g. select
+	// parameters. This could produce unexpected outputs and therefore break the globalSupportedTimeZones variable.
+	if err := loadGlobalSupportedTimeZones(u, compileListQueryOptions(opts)); err != nil {
+		return nil, err
+	}
+
+	resource := fmt.Sprintf("/users/%v/calendar/calendarview", u.ID)
+
+	// set GET-Params for start and end time
+	var reqOpt = compileListQueryOptions(opts)
+	reqOpt.queryValues.Add("startdatetime", startDateTime.Format("2006-01-02T00:00:00"))
+	reqOpt.queryValues.Add("enddatetime", endDateTime.Format("2006-01-02T00:00:00"))
+
+	return &CalendarEventsIterator{newPageIterator(u.graphClient, resource, reqOpt)}, nil
+}
+
+// ListCalendarViewDelta returns the CalendarEvents of the user's default calendar within the specified start-
+// and endDateTime on the first call, respectively only the events that have been added, changed or removed since
+// the delta query of the given ListWithDeltaLink. In that case start- and endDateTime are ignored, because they
+// are part of the deltaLink. Persist the returned DeltaLink to get the next changes later on.
+// Returns an error if the user it not GraphClient sourced or if there is any error during the API-call.
+// Supports optional OData query parameters https://docs.microsoft.com/en-us/graph/query-parameters
+//
+// See https://docs.microsoft.com/en-us/graph/api/event-delta
+func (u User) ListCalendarViewDelta(startDateTime, endDateTime time.Time, opts ...ListQueryOption) (CalendarEventsDelta, error) {
+	if u.graphClient == nil {
+		return CalendarEventsDelta{}, ErrNotGraphClientSourced
+	}
+
+	// TODO: this is a dirty fix, because opts could contain other things than a context, e.g. select
+	// parameters. This could produce unexpected outputs and therefore break the globalSupportedTimeZones variable.
+	if err := loadGlobalSupportedTimeZones(u, compileListQueryOptions(opts)); err != nil {
+		return CalendarEventsDelta{}, err
+	}
+
+	resource := fmt.Sprintf("/users/%v/calendarView/delta", u.ID)
+
+	// set GET-Params for start and end time, delta queries require the full date and time
+	var reqOpt = compileListQueryOptions(opts)
+	reqOpt.queryValues.Add("startDateTime", startDateTime.UTC().Format(time.RFC3339))
+	reqOpt.queryValues.Add("endDateTime", endDateTime.UTC().Format(time.RFC3339))
+
+	items, deltaLink, err := u.graphClient.deltaQuery(resource, reqOpt)
+	if err != nil {
+		return CalendarEventsDelta{}, err
+	}
+
+	delta := CalendarEventsDelta{DeltaLink: deltaLink}
+	delta.Removed, err = splitDeltaItems(items, func(item json.RawMessage, _ deltaItem) error {
+		var event CalendarEvent
+		if err := json.Unmarshal(item, &event); err != nil {
+			return err
+		}
+		delta.CalendarEvents = append(delta.CalendarEvents, event)
+		return nil
+	})
+	if err != nil {
+		return CalendarEventsDelta{}, err
+	}
+	delta.CalendarEvents = delta.CalendarEvents.setGraphClient(u.graphClient)
+	return delta, nil
+}
+
 // getTimeZoneChoices grabs all supported time zones from microsoft for this user.
 // This should actually be the same for every user. Only used internally by this
 // msgraph package.
@@ -210,20 +258,7 @@ func (u User) PrettySimpleString() string {
 //
 // Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user-update
 func (u User) UpdateUser(userInput User, opts ...UpdateQueryOption) error {
-	if u.graphClient == nil {
-		return ErrNotGraphClientSourced
-	}
-	resource := fmt.Sprintf("/users/%v", u.ID)
-
-	bodyBytes, err := json.Marshal(userInput)
-	if err != nil {
-		return err
-	}
-
-	reader := bytes.NewReader(bodyBytes)
-	// Hint: API-call body does not return any data / no json object.
-	err = u.graphClient.makePATCHAPICall(resource, compileUpdateQueryOptions(opts), reader, nil)
-	return err
+	return u.item().Update(userInput, opts...)
 }
 
 // DisableAccount disables the User-Account, hence sets the AccountEnabled-field to false.
@@ -233,36 +268,16 @@ func (u User) UpdateUser(userInput User, opts ...UpdateQueryOption) error {
 //
 // Reference: https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/user-update
 func (u User) DisableAccount(opts ...UpdateQueryOption) error {
-	if u.graphClient == nil {
-		return ErrNotGraphClientSourced
-	}
-	resource := fmt.Sprintf("/users/%v", u.ID)
-
-	bodyBytes, err := json.Marshal(struct {
+	return u.item().Update(struct {
 		AccountEnabled bool `json:"accountEnabled"`
-	}{AccountEnabled: false})
-	if err != nil {
-		return err
-	}
-
-	reader := bytes.NewReader(bodyBytes)
-	// Hint: API-call body does not return any data / no json object.
-	err = u.graphClient.makePATCHAPICall(resource, compileUpdateQueryOptions(opts), reader, nil)
-	return err
+	}{AccountEnabled: false}, opts...)
 }
 
 // DeleteUser deletes this user instance at the Microsoft Azure AD. Use with caution.
 //
 // Reference: https://docs.microsoft.com/en-us/graph/api/user-delete
 func (u User) DeleteUser(opts ...DeleteQueryOption) error {
-	if u.graphClient == nil {
-		return ErrNotGraphClientSourced
-	}
-	resource := fmt.Sprintf("/users/%v", u.ID)
-
-	// TODO: check return body, maybe there is some potential success or error message hidden in it?
-	err := u.graphClient.makeDELETEAPICall(resource, compileDeleteQueryOptions(opts), nil)
-	return err
+	return u.item().Delete(opts...)
 }
 
 // Equal returns wether the user equals the other User by comparing every property
@@ -279,39 +294,24 @@ func (u User) Equal(other User) bool {
 }
 
 func (u User) ListCategories(opts ...ListQueryOption) (OutlookCategories, error) {
-	if u.graphClient == nil {
-		return OutlookCategories{}, ErrNotGraphClientSourced
-	}
-	resource := fmt.Sprintf("/users/%v/outlook/masterCategories", u.ID)
-
-	var marsh struct {
-		OutlookCategories `json:"value"`
-	}
-	err := u.graphClient.makeGETAPICall(resource, compileListQueryOptions(opts), &marsh)
-	marsh.OutlookCategories.setGraphClient(&u)
-	return marsh.OutlookCategories, err
+	categories, err := u.categories().List(opts...)
+	return OutlookCategories(categories).setGraphClient(&u), err
 }
 
 func (u User) CreateOutlookCategory(name string, opts ...CreateQueryOption) (OutlookCategory, error) {
-
-	if u.graphClient == nil {
-		return OutlookCategory{}, ErrNotGraphClientSourced
-	}
-
-	resource := fmt.Sprintf("/users/%v/outlook/masterCategories", u.ID)
-	category := OutlookCategory{graphClient: u.graphClient, user: &u}
-	bodyBytes, err := json.Marshal(struct {
+	category, err := u.categories().Create(struct {
 		Name string `json:"name"`
-	}{Name: name})
-	if err != nil {
-		return OutlookCategory{}, err
-	}
-	if err != nil {
-		return category, err
-	}
-
-	reader := bytes.NewReader(bodyBytes)
-	err = u.graphClient.makePOSTAPICall(resource, compileCreateQueryOptions(opts), reader, &category)
-	category.setGraphClient(&u)
+	}{Name: name}, opts...)
+	category.user = &u
 	return category, err
+}
+
+// item returns the Item of this user for the generic API-calls
+func (u User) item() Item[User] {
+	return NewItem[User](u.graphClient, fmt.Sprintf("/users/%v", u.ID))
+}
+
+// categories returns the Collection of the outlook categories of this user
+func (u User) categories() Collection[OutlookCategory] {
+	return NewCollection[OutlookCategory](u.graphClient, fmt.Sprintf("/users/%v/outlook/masterCategories", u.ID))
 }
\ No newline at end of file
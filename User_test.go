@@ -1,6 +1,7 @@
 package msgraph
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -166,6 +167,32 @@ func TestUser_String(t *testing.T) {
 	})
 }
 
+func TestSignInActivity_JSON(t *testing.T) {
+	lastSignIn := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	activity := SignInActivity{LastSignInDateTime: &lastSignIn, LastSignInRequestID: "req-1"}
+
+	data, err := json.Marshal(activity)
+	if err != nil {
+		t.Fatalf("json.Marshal(SignInActivity) error = %v", err)
+	}
+	if want := `{"lastSignInDateTime":"2024-03-01T08:30:00Z","lastSignInRequestId":"req-1"}`; string(data) != want {
+		t.Errorf("json.Marshal(SignInActivity) = %s, want %s", data, want)
+	}
+
+	var got SignInActivity
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal(SignInActivity) error = %v", err)
+	}
+	if got.LastSignInDateTime == nil || !got.LastSignInDateTime.Equal(lastSignIn) || got.LastSignInRequestID != "req-1" ||
+		got.LastNonInteractiveSignInDateTime != nil || got.LastSuccessfulSignInDateTime != nil {
+		t.Errorf("json.Unmarshal(SignInActivity) = %+v, want %+v", got, activity)
+	}
+
+	if err := json.Unmarshal([]byte(`{"lastSignInDateTime":null}`), &got); err != nil || got.LastSignInDateTime != nil {
+		t.Errorf("json.Unmarshal(SignInActivity) of a User that never signed in = %+v, %v, want nil time", got, err)
+	}
+}
+
 func TestUser_UpdateUser(t *testing.T) {
 	// testing for ErrNotGraphClientSourced
 	notGraphClientSourcedUser := User{ID: "none"}
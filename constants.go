@@ -62,12 +62,24 @@ const (
 	ServiceRootEndpointChina string = "https://microsoftgraph.chinacloudapi.cn"
 )
 
-// APIVersion represents the APIVersion of msgraph used by this implementation
+// APIVersion represents the APIVersion of msgraph used by this implementation, it is the default API version
+// of every GraphClient unless ClientWithAPIVersion is passed
 const APIVersion string = "v1.0"
 
+// APIVersionBeta is the beta API version of msgraph, pass it to ClientWithAPIVersion or e.g. GetWithAPIVersion
+// to use APIs and properties that are only available in beta. Beta APIs are subject to change, hence they
+// must not be used in production applications.
+//
+// See https://docs.microsoft.com/en-us/graph/versioning-and-support
+const APIVersionBeta string = "beta"
+
 // MaxPageSize is the maximum Page size for an API-call. This will be rewritten to use paging some day. Currently limits environments to 999 entries (e.g. Users, CalendarEvents etc.)
 const MaxPageSize int = 999
 
+// MaxConflictRetries is the number of times Item.Mutate re-reads a resource and reapplies the mutation after
+// the update failed with ErrPreconditionFailed due to a concurrent change
+const MaxConflictRetries int = 3
+
 var (
 	// ErrFindUser is returned on any func that tries to find a user with the given parameters that cannot be found
 	ErrFindUser = errors.New("unable to find user")
@@ -81,5 +93,28 @@ var (
 	ErrFindCalendarGroup = errors.New("unable to find calendar group")
 	ErrFindCalendarEvent = errors.New("unable to find calendar event")
 	ErrFindOutlookCategory = errors.New("unable to find outlook category")
+	// ErrNoMorePages is returned by the Next func of an iterator, e.g. UsersIterator, if the last page has already been loaded
+	ErrNoMorePages = errors.New("no more pages")
+	// ErrNotFound is matched by errors.Is if the ms graph API returned a GraphError with StatusCode 404
+	ErrNotFound = errors.New("resource not found")
+	// ErrForbidden is matched by errors.Is if the ms graph API returned a GraphError with StatusCode 403
+	ErrForbidden = errors.New("access forbidden")
+	// ErrUnauthorized is matched by errors.Is if the ms graph API returned a GraphError with StatusCode 401
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrThrottled is matched by errors.Is if the ms graph API returned a GraphError with StatusCode 429
+	ErrThrottled = errors.New("request throttled")
+	// ErrConflict is matched by errors.Is if the ms graph API returned a GraphError with StatusCode 409, e.g. if
+	// the resource to create already exists. Concurrent changes are reported with ErrPreconditionFailed instead.
+	ErrConflict = errors.New("conflict")
+	// ErrPreconditionFailed is matched by errors.Is if the ms graph API returned a GraphError with StatusCode 412.
+	// It is the error of optimistic concurrency: the resource has been changed since it was read, hence the
+	// If-Match of e.g. UpdateWithConcurrencyCheck does not match anymore. See Item.Mutate to retry.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrNoETag is returned by updates with UpdateWithConcurrencyCheck if no @odata.etag has been captured when
+	// the resource was read
+	ErrNoETag = errors.New("no etag captured at read time, cannot send If-Match")
+	// ErrResyncRequired is matched by errors.Is if the ms graph API returned a GraphError with StatusCode 410, e.g.
+	// if a delta link has expired. Start a new delta query without ListWithDeltaLink in that case.
+	ErrResyncRequired = errors.New("resync required")
 	HttpRequestTimeout = time.Second * 10
 )
@@ -0,0 +1,204 @@
+// Package filter builds OData $filter expressions for msgraph.ListWithFilter. String values are quoted and
+// escaped, time.Time values are formatted in UTC and GUIDs are rendered as GUID literals, hence user input
+// such as display names containing quotes cannot break the expression.
+//
+//	f := filter.And(
+//		filter.Eq("department", "Sales"),
+//		filter.Or(filter.StartsWith("displayName", "O'Brien"), filter.Eq("accountEnabled", false)),
+//	)
+//	users, err := graphClient.ListUsers(msgraph.ListWithFilter(f.String()))
+//
+// See https://docs.microsoft.com/en-us/graph/query-parameters#filter-parameter
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expr is an OData $filter expression, use String to pass it to msgraph.ListWithFilter. The zero value is an
+// empty expression that is skipped by And and Or.
+type Expr struct {
+	expr string
+	op   string // the logical operator joining the operands, "and" or "or", "raw" for Raw, empty for any other expression
+}
+
+// String returns the expression as string consumed by msgraph.ListWithFilter
+func (e Expr) String() string {
+	return e.expr
+}
+
+// IsEmpty returns true if the expression is empty, e.g. And without operands
+func (e Expr) IsEmpty() bool {
+	return e.expr == ""
+}
+
+// Raw returns the given expression as-is, e.g. for functions not supported by this package. The expression
+// is neither validated nor escaped, hence it must not contain user input. And and Or put it in parentheses.
+func Raw(expr string) Expr {
+	return Expr{expr: expr, op: "raw"}
+}
+
+// GUID is a value that is rendered as GUID literal, e.g. the skuId of an assigned license. A value that is
+// not a valid GUID is rendered as string literal instead.
+type GUID string
+
+var guidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Literal returns the OData literal of the given value. Strings are quoted with single quotes doubled,
+// time.Time is formatted as RFC3339 in UTC, GUID as GUID literal and nil as null. Other types are
+// formatted as string literal with fmt.Sprint.
+func Literal(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return quote(v)
+	case GUID:
+		if guidPattern.MatchString(string(v)) {
+			return string(v)
+		}
+		return quote(string(v))
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return quote(fmt.Sprint(v))
+	}
+}
+
+// quote returns the given string as OData string literal
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// compare returns the comparison of the property with the value using the given operator
+func compare(property, operator string, value interface{}) Expr {
+	return Expr{expr: property + " " + operator + " " + Literal(value)}
+}
+
+// Eq returns "property eq value"
+func Eq(property string, value interface{}) Expr {
+	return compare(property, "eq", value)
+}
+
+// Ne returns "property ne value"
+func Ne(property string, value interface{}) Expr {
+	return compare(property, "ne", value)
+}
+
+// Gt returns "property gt value"
+func Gt(property string, value interface{}) Expr {
+	return compare(property, "gt", value)
+}
+
+// Ge returns "property ge value"
+func Ge(property string, value interface{}) Expr {
+	return compare(property, "ge", value)
+}
+
+// Lt returns "property lt value"
+func Lt(property string, value interface{}) Expr {
+	return compare(property, "lt", value)
+}
+
+// Le returns "property le value"
+func Le(property string, value interface{}) Expr {
+	return compare(property, "le", value)
+}
+
+// StartsWith returns "startswith(property, 'value')"
+func StartsWith(property, value string) Expr {
+	return Expr{expr: "startswith(" + property + ", " + quote(value) + ")"}
+}
+
+// EndsWith returns "endswith(property, 'value')", which is an advanced query on directory objects and
+// requires msgraph.ListWithCount
+func EndsWith(property, value string) Expr {
+	return Expr{expr: "endswith(" + property + ", " + quote(value) + ")"}
+}
+
+// In returns "property in (value1, value2, ...)"
+func In(property string, values ...interface{}) Expr {
+	literals := make([]string, len(values))
+	for i, value := range values {
+		literals[i] = Literal(value)
+	}
+	return Expr{expr: property + " in (" + strings.Join(literals, ", ") + ")"}
+}
+
+// Any returns "collection/any(variable:predicate)", true if the predicate is true for any item of the collection.
+// The predicate refers to the item with the variable, e.g.
+//
+//	filter.Any("assignedLicenses", "l", filter.Eq("l/skuId", filter.GUID(skuID)))
+//	filter.Any("proxyAddresses", "p", filter.StartsWith("p", "smtp:"))
+func Any(collection, variable string, predicate Expr) Expr {
+	return Expr{expr: collection + "/any(" + variable + ":" + predicate.expr + ")"}
+}
+
+// All returns "collection/all(variable:predicate)", true if the predicate is true for all items of the
+// collection, see Any
+func All(collection, variable string, predicate Expr) Expr {
+	return Expr{expr: collection + "/all(" + variable + ":" + predicate.expr + ")"}
+}
+
+// Not returns "not (expr)"
+func Not(e Expr) Expr {
+	return Expr{expr: "not (" + e.expr + ")"}
+}
+
+// And returns the conjunction of the given expressions, "or" expressions are put in parentheses. Empty
+// expressions are skipped, hence And without operands returns an empty expression.
+func And(exprs ...Expr) Expr {
+	return join("and", exprs)
+}
+
+// Or returns the disjunction of the given expressions, "and" expressions are put in parentheses for
+// readability. Empty expressions are skipped, hence Or without operands returns an empty expression.
+func Or(exprs ...Expr) Expr {
+	return join("or", exprs)
+}
+
+// join joins the non-empty expressions with the given logical operator
+func join(op string, exprs []Expr) Expr {
+	var parts []string
+	var last Expr
+	for _, e := range exprs {
+		switch {
+		case e.IsEmpty():
+			continue
+		case e.op != "" && e.op != op:
+			parts = append(parts, "("+e.expr+")")
+		default:
+			parts = append(parts, e.expr)
+		}
+		last = e
+	}
+	switch len(parts) {
+	case 0:
+		return Expr{}
+	case 1:
+		return last
+	}
+	return Expr{expr: strings.Join(parts, " "+op+" "), op: op}
+}
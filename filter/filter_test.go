@@ -0,0 +1,71 @@
+package filter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{name: "string", value: "Sales", want: "'Sales'"},
+		{name: "string with quotes", value: "O'Brien's", want: "'O''Brien''s'"},
+		{name: "empty string", value: "", want: "''"},
+		{name: "bool", value: true, want: "true"},
+		{name: "int", value: 42, want: "42"},
+		{name: "int64", value: int64(-7), want: "-7"},
+		{name: "float", value: 1.5, want: "1.5"},
+		{name: "nil", value: nil, want: "null"},
+		{name: "time", value: time.Date(2021, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600)), want: "2021-03-01T11:30:00Z"},
+		{name: "GUID", value: GUID("8B2F5C0E-1234-4A7B-9C3D-0123456789AB"), want: "8B2F5C0E-1234-4A7B-9C3D-0123456789AB"},
+		{name: "invalid GUID", value: GUID("x' or 1 eq 1"), want: "'x'' or 1 eq 1'"},
+		{name: "other type", value: time.Minute, want: "'1m0s'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Literal(tt.value); got != tt.want {
+				t.Errorf("Literal(%v) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpr(t *testing.T) {
+	skuID := GUID("6fd2c87f-b296-42f0-b197-1e91e994b900")
+	tests := []struct {
+		name string
+		expr Expr
+		want string
+	}{
+		{name: "eq", expr: Eq("displayName", "O'Brien"), want: "displayName eq 'O''Brien'"},
+		{name: "ne", expr: Ne("accountEnabled", false), want: "accountEnabled ne false"},
+		{name: "gt", expr: Gt("createdDateTime", time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)), want: "createdDateTime gt 2021-01-01T00:00:00Z"},
+		{name: "ge", expr: Ge("size", 10), want: "size ge 10"},
+		{name: "lt", expr: Lt("size", 10), want: "size lt 10"},
+		{name: "le", expr: Le("size", 10), want: "size le 10"},
+		{name: "startswith", expr: StartsWith("mail", "a'b"), want: "startswith(mail, 'a''b')"},
+		{name: "endswith", expr: EndsWith("mail", "@contoso.com"), want: "endswith(mail, '@contoso.com')"},
+		{name: "in", expr: In("department", "Sales", "R&D"), want: "department in ('Sales', 'R&D')"},
+		{name: "any", expr: Any("assignedLicenses", "l", Eq("l/skuId", skuID)), want: "assignedLicenses/any(l:l/skuId eq 6fd2c87f-b296-42f0-b197-1e91e994b900)"},
+		{name: "all", expr: All("proxyAddresses", "p", StartsWith("p", "smtp:")), want: "proxyAddresses/all(p:startswith(p, 'smtp:'))"},
+		{name: "not", expr: Not(Eq("department", "Sales")), want: "not (department eq 'Sales')"},
+		{name: "and", expr: And(Eq("a", 1), Eq("b", 2), Eq("c", 3)), want: "a eq 1 and b eq 2 and c eq 3"},
+		{name: "or in and", expr: And(Eq("a", 1), Or(Eq("b", 2), Eq("c", 3))), want: "a eq 1 and (b eq 2 or c eq 3)"},
+		{name: "and in or", expr: Or(And(Eq("a", 1), Eq("b", 2)), Eq("c", 3)), want: "(a eq 1 and b eq 2) or c eq 3"},
+		{name: "nested and", expr: And(And(Eq("a", 1), Eq("b", 2)), Eq("c", 3)), want: "a eq 1 and b eq 2 and c eq 3"},
+		{name: "empty operands", expr: And(Expr{}, Or(), Eq("a", 1)), want: "a eq 1"},
+		{name: "single or operand in and", expr: Or(And(Eq("a", 1), Eq("b", 2))), want: "a eq 1 and b eq 2"},
+		{name: "empty", expr: And(), want: ""},
+		{name: "raw", expr: And(Raw("a eq 1 or b eq 2"), Eq("c", 3)), want: "(a eq 1 or b eq 2) and c eq 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.expr.String(); got != tt.want {
+				t.Errorf("Expr.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
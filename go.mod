@@ -1,3 +1,9 @@
 module github.com/SerenityITS-Development/go-msgraph
 
-go 1.16
+// Hint: go 1.21 is required by log/slog of the debug logging (ClientWithLogger), the generic Collection and
+// Item need go 1.18
+go 1.21
+
+require software.sslmate.com/src/go-pkcs12 v0.7.3
+
+require golang.org/x/crypto v0.11.0 // indirect
@@ -0,0 +1,161 @@
+// Package redact replaces credentials and further sensitive data within headers and bodies of requests and
+// responses. It is shared by the debug logging of the GraphClient and the Recorder of msgraphtest.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Value replaces the values of redacted headers, form fields and JSON properties
+const Value = "REDACTED"
+
+// UnparseableJSON replaces bodies that look like JSON but cannot be decoded, hence cannot be redacted
+const UnparseableJSON = "<unparseable JSON body redacted>"
+
+var (
+	// DefaultHeaders contain credentials, hence they are always redacted
+	DefaultHeaders = []string{"Authorization", "X-Identity-Header", "Cookie", "Set-Cookie"}
+	// DefaultFormFields are the credentials sent to the token endpoint, hence they are always redacted
+	DefaultFormFields = []string{"client_secret", "client_assertion", "assertion", "refresh_token", "code", "device_code", "password"}
+	// DefaultJSONProperties are the tokens returned by the token endpoint and the password of a new user
+	DefaultJSONProperties = []string{"access_token", "refresh_token", "id_token", "device_code", "passwordProfile.password"}
+)
+
+// Redactor redacts headers, form fields and JSON properties. Use New to create one with the defaults.
+type Redactor struct {
+	headers        map[string]bool // canonical header keys to be redacted
+	formFields     map[string]bool
+	jsonProperties [][]string
+}
+
+// New returns a Redactor that redacts DefaultHeaders, DefaultFormFields and DefaultJSONProperties
+func New() *Redactor {
+	r := &Redactor{
+		headers:    make(map[string]bool),
+		formFields: make(map[string]bool),
+	}
+	r.AddHeaders(DefaultHeaders...)
+	r.AddFormFields(DefaultFormFields...)
+	r.AddJSONProperties(DefaultJSONProperties...)
+	return r
+}
+
+// AddHeaders redacts the given headers in addition
+func (r *Redactor) AddHeaders(headers ...string) {
+	for _, header := range headers {
+		r.headers[http.CanonicalHeaderKey(header)] = true
+	}
+}
+
+// AddFormFields redacts the given fields of form-encoded bodies in addition
+func (r *Redactor) AddFormFields(fields ...string) {
+	for _, field := range fields {
+		r.formFields[field] = true
+	}
+}
+
+// AddJSONProperties redacts the given properties of JSON bodies in addition. Nested properties are separated
+// by dots, e.g. "passwordProfile.password", and match at any depth, hence also within arrays.
+func (r *Redactor) AddJSONProperties(properties ...string) {
+	for _, property := range properties {
+		r.jsonProperties = append(r.jsonProperties, strings.Split(property, "."))
+	}
+}
+
+// Header returns Value if the header with the given key is redacted, the given value otherwise
+func (r *Redactor) Header(key, value string) string {
+	if r.headers[http.CanonicalHeaderKey(key)] {
+		return Value
+	}
+	return value
+}
+
+// Body returns the given body with the redacted JSON properties or form fields replaced. The body is sniffed
+// instead of trusting the Content-Type, which may be missing or generic, e.g. text/plain or
+// application/octet-stream. Bodies that look like JSON but cannot be decoded, e.g. truncated token responses,
+// are replaced by UnparseableJSON. Bodies that are neither JSON nor contain a redacted form field are returned
+// as-is.
+func (r *Redactor) Body(body []byte) string {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber() // Hint: keep big numbers as-is
+		var v interface{}
+		if err := decoder.Decode(&v); err != nil {
+			return UnparseableJSON
+		}
+		data, err := json.Marshal(r.redactJSON(v, nil))
+		if err != nil {
+			return UnparseableJSON
+		}
+		return string(data)
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return string(body)
+	}
+	redacted := false
+	for field := range form {
+		if r.formFields[field] {
+			form.Set(field, Value)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return string(body)
+	}
+	return form.Encode()
+}
+
+// URL returns the given URL without its query, as the query of token requests or nextLinks may contain
+// sensitive data
+func URL(u *url.URL) string {
+	withoutQuery := *u
+	withoutQuery.RawQuery = ""
+	return withoutQuery.String()
+}
+
+// redactJSON replaces the values of all redacted properties within the given decoded JSON value, path
+// contains the names of the properties leading to it
+func (r *Redactor) redactJSON(v interface{}, path []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := append(path[:len(path):len(path)], key)
+			if r.isRedactedProperty(childPath) {
+				v[key] = Value
+			} else {
+				v[key] = r.redactJSON(child, childPath)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = r.redactJSON(child, path)
+		}
+	}
+	return v
+}
+
+// isRedactedProperty returns true if the given path of property names ends with any redacted property
+func (r *Redactor) isRedactedProperty(path []string) bool {
+	for _, property := range r.jsonProperties {
+		if len(property) > len(path) {
+			continue
+		}
+		suffix := path[len(path)-len(property):]
+		match := true
+		for i := range property {
+			if suffix[i] != property[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+module github.com/SerenityITS-Development/go-msgraph/msgraphotel
+
+go 1.21
+
+require (
+	github.com/SerenityITS-Development/go-msgraph v0.0.0
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/metric v1.28.0
+	go.opentelemetry.io/otel/sdk v1.28.0
+	go.opentelemetry.io/otel/sdk/metric v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	golang.org/x/crypto v0.11.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	software.sslmate.com/src/go-pkcs12 v0.7.3 // indirect
+)
+
+// Hint: builds against the go-msgraph module of this repository during development
+replace github.com/SerenityITS-Development/go-msgraph => ../
@@ -0,0 +1,188 @@
+// Package msgraphotel instruments the requests of a msgraph.GraphClient with OpenTelemetry. It creates a
+// span per request, hence per API-call and per page of a paginated list, and records the request duration,
+// retries and throttled responses. The span is a child of the span in the context passed to the API-call,
+// e.g. with msgraph.ListWithContext.
+//
+//	graphClient, err := msgraph.NewGraphClient("<TenantID>", "<ApplicationID>", "<ClientSecret>",
+//		msgraph.ClientWithRetryPolicy(msgraph.DefaultRetryPolicy),
+//		msgraph.ClientWithMiddleware(msgraphotel.Middleware()),
+//	)
+//
+// By default the global TracerProvider and MeterProvider are used, see WithTracerProvider and WithMeterProvider.
+package msgraphotel
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	msgraph "github.com/SerenityITS-Development/go-msgraph"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the Tracer and Meter
+const instrumentationName = "github.com/SerenityITS-Development/go-msgraph/msgraphotel"
+
+// Attribute keys of the spans and metrics besides the semantic conventions of HTTP clients
+const (
+	RequestIDKey       = attribute.Key("msgraph.request_id")        // the request-id returned by the ms graph API
+	ClientRequestIDKey = attribute.Key("msgraph.client_request_id") // the client-request-id, see msgraph.ClientRequestIDMiddleware
+	ThrottledKey       = attribute.Key("msgraph.throttled")         // the number of throttled (429) responses of the request
+)
+
+// Option configures the Middleware
+type Option func(c *config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	pathTemplate   func(path string) string
+}
+
+var (
+	// WithTracerProvider - create the spans with the given TracerProvider instead of the global one
+	WithTracerProvider = func(provider trace.TracerProvider) Option {
+		return func(c *config) {
+			c.tracerProvider = provider
+		}
+	}
+
+	// WithMeterProvider - record the metrics with the given MeterProvider instead of the global one
+	WithMeterProvider = func(provider metric.MeterProvider) Option {
+		return func(c *config) {
+			c.meterProvider = provider
+		}
+	}
+
+	// WithPathTemplate - use the given func instead of PathTemplate to replace the IDs of the request path,
+	// e.g. for resources whose IDs are not recognized by PathTemplate
+	WithPathTemplate = func(pathTemplate func(path string) string) Option {
+		return func(c *config) {
+			c.pathTemplate = pathTemplate
+		}
+	}
+)
+
+// Middleware returns a msgraph.Middleware that creates a span per request and records the metrics
+// msgraph.client.request.duration, msgraph.client.retries and msgraph.client.throttled. The metrics are
+// recorded with the HTTP method, the path template and the status code as attributes, see PathTemplate.
+//
+// Errors creating the instruments are handled by the global otel.ErrorHandler, the Middleware then
+// only records the spans.
+func Middleware(opts ...Option) msgraph.Middleware {
+	c := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		pathTemplate:   PathTemplate,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	tracer := c.tracerProvider.Tracer(instrumentationName)
+	meter := c.meterProvider.Meter(instrumentationName)
+	duration, err := meter.Float64Histogram("msgraph.client.request.duration", metric.WithUnit("s"),
+		metric.WithDescription("Duration of the requests to the ms graph API including all retries"))
+	if err != nil {
+		otel.Handle(err)
+	}
+	retries, err := meter.Int64Counter("msgraph.client.retries", metric.WithUnit("{retry}"),
+		metric.WithDescription("Number of retried requests to the ms graph API"))
+	if err != nil {
+		otel.Handle(err)
+	}
+	throttled, err := meter.Int64Counter("msgraph.client.throttled", metric.WithUnit("{response}"),
+		metric.WithDescription("Number of throttled (429) responses of the ms graph API"))
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return func(next msgraph.Handler) msgraph.Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			template := c.pathTemplate(req.URL.Path)
+			attrs := []attribute.KeyValue{
+				attribute.String("http.request.method", req.Method),
+				attribute.String("url.template", template),
+				attribute.String("server.address", req.URL.Hostname()),
+			}
+			ctx, span := tracer.Start(req.Context(), req.Method+" "+template,
+				trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+			defer span.End()
+
+			var stats msgraph.RequestStats
+			start := time.Now()
+			resp, err := next(msgraph.WithRequestStats(req.WithContext(ctx), &stats))
+			elapsed := time.Since(start)
+
+			if resp != nil {
+				status := attribute.Int("http.response.status_code", resp.StatusCode)
+				attrs = append(attrs, status)
+				span.SetAttributes(status)
+				if id := resp.Header.Get("request-id"); id != "" {
+					span.SetAttributes(RequestIDKey.String(id))
+				}
+			}
+			if id := req.Header.Get("client-request-id"); id != "" {
+				span.SetAttributes(ClientRequestIDKey.String(id))
+			}
+			if stats.Attempts > 1 {
+				span.SetAttributes(attribute.Int("http.request.resend_count", stats.Attempts-1))
+			}
+			if stats.Throttled > 0 {
+				span.SetAttributes(ThrottledKey.Int(stats.Throttled))
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			set := metric.WithAttributes(attrs...)
+			if duration != nil {
+				duration.Record(ctx, elapsed.Seconds(), set)
+			}
+			if retries != nil && stats.Attempts > 1 {
+				retries.Add(ctx, int64(stats.Attempts-1), set)
+			}
+			if throttled != nil && stats.Throttled > 0 {
+				throttled.Add(ctx, int64(stats.Throttled), set)
+			}
+			return resp, err
+		}
+	}
+}
+
+var guidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// PathTemplate returns the given request path with its IDs replaced by {id}, hence the spans and metrics
+// of e.g. all users are aggregated: /v1.0/users/alice@contoso.com/calendars returns /v1.0/users/{id}/calendars.
+//
+// A segment is considered an ID if it is a GUID, contains an @ or follows a collection, hence a segment
+// ending with s like users, groups or events. Functions like delta and segments starting with $ are kept.
+func PathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" || i == 0 {
+			continue
+		}
+		if guidPattern.MatchString(segment) || strings.Contains(segment, "@") {
+			segments[i] = "{id}"
+			continue
+		}
+		if previous := segments[i-1]; !strings.HasSuffix(previous, "s") || isFunction(segment) {
+			continue
+		}
+		segments[i] = "{id}"
+	}
+	return strings.Join(segments, "/")
+}
+
+// isFunction returns true if the given segment is a function or system query like delta or $count
+func isFunction(segment string) bool {
+	return segment == "delta" || strings.HasPrefix(segment, "$") || strings.Contains(segment, "(") ||
+		strings.HasPrefix(segment, "microsoft.graph.")
+}
@@ -0,0 +1,172 @@
+package msgraphotel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	msgraph "github.com/SerenityITS-Development/go-msgraph"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestPathTemplate(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/v1.0/users", want: "/v1.0/users"},
+		{path: "/v1.0/users/alice@contoso.com", want: "/v1.0/users/{id}"},
+		{path: "/v1.0/users/7f9d5fc6-2d4b-4fb1-9b5a-0d5c0f1a2b3c/calendars/AAMkAGI2TG93AAA=/events",
+			want: "/v1.0/users/{id}/calendars/{id}/events"},
+		{path: "/beta/groups/delta", want: "/beta/groups/delta"},
+		{path: "/v1.0/groups/1/members/$ref", want: "/v1.0/groups/{id}/members/$ref"},
+		{path: "/v1.0/users/bob/mailboxSettings", want: "/v1.0/users/{id}/mailboxSettings"},
+		{path: "/v1.0/$batch", want: "/v1.0/$batch"},
+		{path: "/7f9d5fc6-2d4b-4fb1-9b5a-0d5c0f1a2b3c/oauth2/v2.0/token", want: "/{id}/oauth2/v2.0/token"},
+	}
+	for _, tt := range tests {
+		if got := PathTemplate(tt.path); got != tt.want {
+			t.Errorf("PathTemplate(%v) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var calls int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/oauth2/") {
+			fmt.Fprintf(w, `{"token_type":"Bearer","expires_on":"%d","not_before":"%d","access_token":"test-token"}`,
+				time.Now().Add(time.Hour).Unix(), time.Now().Add(-time.Minute).Unix())
+			return
+		}
+		w.Header().Set("request-id", fmt.Sprintf("request-%d", atomic.AddInt32(&calls, 1)))
+		if atomic.LoadInt32(&calls) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if r.URL.Query().Get("$skiptoken") == "" {
+			fmt.Fprintf(w, `{"value":[{"id":"1"}],"@odata.nextLink":"%v/v1.0/users?$skiptoken=2"}`, srv.URL)
+			return
+		}
+		fmt.Fprint(w, `{"value":[{"id":"2"}]}`)
+	}))
+	defer srv.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	g, err := msgraph.NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL,
+		msgraph.ClientWithLazyInit(),
+		msgraph.ClientWithRetryPolicy(msgraph.RetryPolicy{MaxRetries: 1}),
+		msgraph.ClientWithMiddleware(msgraph.ClientRequestIDMiddleware(),
+			Middleware(WithTracerProvider(tracerProvider), WithMeterProvider(meterProvider))),
+	)
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+
+	ctx, parent := tracerProvider.Tracer("test").Start(context.Background(), "parent")
+	users, err := g.ListUsers(msgraph.ListWithContext(ctx))
+	parent.End()
+	if err != nil || len(users) != 2 {
+		t.Fatalf("GraphClient.ListUsers() = %v, %v, want 2 users", users, err)
+	}
+
+	var pages []sdktrace.ReadOnlySpan
+	for _, span := range spans.Ended() {
+		if span.Name() == "GET /v1.0/users" {
+			pages = append(pages, span)
+		}
+	}
+	if len(pages) != 2 {
+		t.Fatalf("spans %v, want one per page", spans.Ended())
+	}
+	for _, page := range pages {
+		if page.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %v is not a child of the span of the context", page.Name())
+		}
+	}
+	first := attributes(pages[0].Attributes())
+	if first["http.request.resend_count"] != "1" || first[string(ThrottledKey)] != "1" || first[string(RequestIDKey)] != "request-2" {
+		t.Errorf("attributes of the first page = %v, want a retry after a throttled response", first)
+	}
+	if first["http.response.status_code"] != "200" || first[string(ClientRequestIDKey)] == "" {
+		t.Errorf("attributes of the first page = %v, want status code and client-request-id", first)
+	}
+	if second := attributes(pages[1].Attributes()); second["http.request.resend_count"] != "" {
+		t.Errorf("attributes of the second page = %v, want no retry", second)
+	}
+
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &metrics); err != nil {
+		t.Fatalf("ManualReader.Collect() error = %v", err)
+	}
+	got := map[string]int64{}
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, point := range data.DataPoints {
+					got[m.Name] += point.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, point := range data.DataPoints {
+					got[m.Name] += int64(point.Count)
+				}
+			}
+		}
+	}
+	// Hint: the token request is recorded as well
+	want := map[string]int64{"msgraph.client.request.duration": 3, "msgraph.client.retries": 1, "msgraph.client.throttled": 1}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("metric %v = %v, want %v", name, got[name], value)
+		}
+	}
+}
+
+func TestMiddleware_error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":"invalid_client","error_description":"wrong secret"}`)
+	}))
+	defer srv.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	_, err := msgraph.NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL,
+		msgraph.ClientWithMiddleware(Middleware(WithTracerProvider(tracerProvider))))
+	if err == nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = nil, want error of the token request")
+	}
+	ended := spans.Ended()
+	if len(ended) != 1 || ended[0].Status().Code != codes.Error {
+		t.Fatalf("spans = %v, want one span with error status", ended)
+	}
+	if attrs := attributes(ended[0].Attributes()); attrs["http.response.status_code"] != "401" {
+		t.Errorf("attributes = %v, want status code 401", attrs)
+	}
+}
+
+// attributes returns the given attributes as map of strings
+func attributes(kvs []attribute.KeyValue) map[string]string {
+	res := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		res[string(kv.Key)] = kv.Value.Emit()
+	}
+	return res
+}
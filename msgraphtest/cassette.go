@@ -0,0 +1,332 @@
+package msgraphtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SerenityITS-Development/go-msgraph/internal/redact"
+)
+
+// RecorderMode defines whether a Recorder records or replays API-calls
+type RecorderMode int
+
+const (
+	// Replay answers requests with the interactions of the cassette file without network, requests
+	// without a matching interaction fail
+	Replay RecorderMode = iota
+	// Record sends requests with the underlying transport and records them, Save writes the cassette file
+	Record
+)
+
+// ScrubbedValue replaces the values of scrubbed headers, form fields and JSON properties in cassettes
+const ScrubbedValue = redact.Value
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a scrubbed request of an Interaction
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`            // the path without host, e.g. "/v1.0/users"
+	Query  string      `json:"query,omitempty"` // the normalized query, see NormalizeQuery
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a scrubbed response of an Interaction
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// RecorderOption configures a Recorder upon creation, see NewRecorder
+type RecorderOption func(r *Recorder)
+
+var (
+	// RecordWithTransport - send the requests with the given http.RoundTripper instead of http.DefaultTransport
+	// when recording
+	RecordWithTransport = func(transport http.RoundTripper) RecorderOption {
+		return func(r *Recorder) {
+			r.transport = transport
+		}
+	}
+
+	// RecordWithScrubbedHeaders - scrub the given headers in addition to Authorization, X-Identity-Header,
+	// Cookie and Set-Cookie
+	RecordWithScrubbedHeaders = func(headers ...string) RecorderOption {
+		return func(r *Recorder) {
+			r.redactor.AddHeaders(headers...)
+		}
+	}
+
+	// RecordWithScrubbedFormFields - scrub the given fields of form-encoded bodies in addition to the credentials
+	// sent to the token endpoint, e.g. client_secret and refresh_token
+	RecordWithScrubbedFormFields = func(fields ...string) RecorderOption {
+		return func(r *Recorder) {
+			r.redactor.AddFormFields(fields...)
+		}
+	}
+
+	// RecordWithScrubbedJSONProperties - scrub the given properties of JSON bodies in addition to the tokens
+	// and passwordProfile.password, e.g. personal data like "mobilePhone". Nested properties are separated
+	// by dots, e.g. "passwordProfile.password", and match at any depth.
+	RecordWithScrubbedJSONProperties = func(properties ...string) RecorderOption {
+		return func(r *Recorder) {
+			r.redactor.AddJSONProperties(properties...)
+		}
+	}
+
+	// RecordWithReplacement - replace all occurrences of old with new in recorded paths, queries, headers
+	// and bodies, e.g. the tenant ID or the userPrincipalName of a real user. When replaying, the replacement
+	// is applied to the requests before matching, hence tests may use either value. An empty old is ignored.
+	RecordWithReplacement = func(old, new string) RecorderOption {
+		return func(r *Recorder) {
+			if old != "" && old != new {
+				r.replacements = append(r.replacements, old, new)
+			}
+		}
+	}
+)
+
+// Recorder is a http.RoundTripper that records API-calls to a cassette file, respectively replays them from
+// it. Record once against a real tenant, then replay the sanitized interactions deterministically without
+// network, e.g. in CI. Use it with msgraph.ClientWithRoundTripper.
+//
+// Recorded requests and responses are scrubbed: credentials and tokens are always replaced with
+// ScrubbedValue, further personal data may be scrubbed with RecorderOptions. The absolute expiry of tokens,
+// expires_on and not_before, is recorded relative to the time of the response as expires_in, hence replayed
+// tokens do not expire. Replayed requests are matched on method, path and normalized query, each Interaction
+// is replayed once in recorded order.
+type Recorder struct {
+	path         string
+	mode         RecorderMode
+	transport    http.RoundTripper
+	redactor     *redact.Redactor
+	replacements []string // pairs of old and new, see strings.NewReplacer
+
+	lock         sync.Mutex
+	interactions []Interaction
+	replayed     []bool
+}
+
+// NewRecorder returns a Recorder for the cassette file with the given path. In Replay mode the file is
+// loaded and must exist, in Record mode it is written by Save.
+func NewRecorder(path string, mode RecorderMode, opts ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		redactor:  redact.New(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == Replay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal cassette %v: %w", path, err)
+		}
+		r.replayed = make([]bool, len(r.interactions))
+	}
+	return r, nil
+}
+
+// Interactions returns the recorded respectively loaded interactions
+func (r *Recorder) Interactions() []Interaction {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the recorded interactions to the cassette file, creating its directory if necessary.
+// Does nothing in Replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != Record {
+		return nil
+	}
+	r.lock.Lock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.lock.Unlock()
+	if err != nil {
+		return fmt.Errorf("cannot marshal cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("cannot create directory of cassette: %w", err)
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// RoundTrip records or replays the given request, see Recorder
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == Replay {
+		return r.replay(req)
+	}
+	return r.record(req)
+}
+
+// record sends the given request with the transport and records it and its response
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			Path:   r.replace(req.URL.Path),
+			Query:  r.normalizeQuery(req.URL.Query()),
+			Header: r.scrubHeader(req.Header),
+			Body:   r.replace(r.redactor.Body(reqBody)),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.scrubHeader(resp.Header),
+			Body:       r.replace(r.redactor.Body(relativeTokenExpiry(resp, respBody))),
+		},
+	}
+	r.lock.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.lock.Unlock()
+	return resp, nil
+}
+
+// replay answers the given request with the first matching interaction that has not been replayed yet
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	path, query := r.replace(req.URL.Path), r.normalizeQuery(req.URL.Query())
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for i, interaction := range r.interactions {
+		recorded := interaction.Request
+		if r.replayed[i] || recorded.Method != req.Method || recorded.Path != path || recorded.Query != query {
+			continue
+		}
+		r.replayed[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("msgraphtest: no recorded interaction left for %v %v?%v in cassette %v", req.Method, path, query, r.path)
+}
+
+// replace applies the replacements to the given string
+func (r *Recorder) replace(s string) string {
+	if len(r.replacements) == 0 {
+		return s
+	}
+	return strings.NewReplacer(r.replacements...).Replace(s)
+}
+
+// normalizeQuery returns the given query with the replacements applied, sorted by key, see NormalizeQuery
+func (r *Recorder) normalizeQuery(query url.Values) string {
+	replaced := make(url.Values, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			replaced.Add(r.replace(key), r.replace(value))
+		}
+	}
+	return NormalizeQuery(replaced)
+}
+
+// NormalizeQuery returns the given query encoded with sorted keys and the values of each key in their original
+// order, hence queries with a different order of parameters or a different percent-encoding are equal
+func NormalizeQuery(query url.Values) string {
+	return query.Encode()
+}
+
+// scrubHeader returns a copy of the given headers with the scrubbed headers replaced and the replacements applied
+func (r *Recorder) scrubHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	res := make(http.Header, len(header))
+	for key, values := range header {
+		for _, value := range values {
+			res.Add(key, r.replace(r.redactor.Header(key, value)))
+		}
+	}
+	return res
+}
+
+// relativeTokenExpiry returns the given body of a token response with the absolute expires_on and not_before
+// replaced by expires_in relative to the Date of the response, respectively the current time without Date.
+// Other bodies are returned as-is.
+func relativeTokenExpiry(resp *http.Response, body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var token map[string]interface{}
+	if err := decoder.Decode(&token); err != nil || token["access_token"] == nil || token["expires_on"] == nil {
+		return body
+	}
+	expiresOn, err := strconv.ParseInt(fmt.Sprint(token["expires_on"]), 10, 64)
+	if err != nil {
+		return body
+	}
+	issued := time.Now()
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		issued = date
+	}
+	if token["expires_in"] == nil {
+		// Hint: the v1 and managed identity endpoints send numbers as strings
+		expiresIn := strconv.FormatInt(expiresOn-issued.Unix(), 10)
+		if _, isString := token["expires_on"].(string); isString {
+			token["expires_in"] = expiresIn
+		} else {
+			token["expires_in"] = json.Number(expiresIn)
+		}
+	}
+	delete(token, "expires_on")
+	delete(token, "not_before")
+	data, err := json.Marshal(token)
+	if err != nil {
+		return body
+	}
+	return data
+}
@@ -0,0 +1,146 @@
+package msgraphtest_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	msgraph "github.com/SerenityITS-Development/go-msgraph"
+	"github.com/SerenityITS-Development/go-msgraph/msgraphtest"
+)
+
+func TestRecorder(t *testing.T) {
+	srv := msgraphtest.NewServer()
+	srv.TenantID = "real-tenant-id"
+	alice := srv.AddUser(msgraphtest.Object{"displayName": "Alice", "userPrincipalName": "alice@realcorp.test", "mobilePhone": "+1 555 0100"})
+	for _, name := range []string{"Bob", "Carol"} {
+		srv.AddUser(msgraphtest.Object{"displayName": name, "userPrincipalName": strings.ToLower(name) + "@realcorp.test"})
+	}
+	cassette := filepath.Join(t.TempDir(), "fixtures", "users.json")
+	scrubbing := []msgraphtest.RecorderOption{
+		msgraphtest.RecordWithScrubbedJSONProperties("mobilePhone"),
+		msgraphtest.RecordWithReplacement("real-tenant-id", "tenant"),
+		msgraphtest.RecordWithReplacement("realcorp.test", "contoso.test"),
+	}
+
+	// record against the server
+	recorder, err := msgraphtest.NewRecorder(cassette, msgraphtest.Record, scrubbing...)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	g, err := msgraph.NewGraphClientWithCustomEndpoint("real-tenant-id", "app", "client-secret-value", srv.URL, srv.URL,
+		msgraph.ClientWithRoundTripper(recorder))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+	recordedUsers, err := g.ListUsers(msgraph.ListWithPageSize(2), msgraph.ListWithOrderBy("displayName"))
+	if err != nil {
+		t.Fatalf("GraphClient.ListUsers() error = %v", err)
+	}
+	if _, err := g.GetUser(alice["id"].(string)); err != nil {
+		t.Fatalf("GraphClient.GetUser() error = %v", err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Recorder.Save() error = %v", err)
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatalf("cannot read cassette: %v", err)
+	}
+	for _, secret := range []string{"client-secret-value", "msgraphtest-token", "+1 555 0100", "real-tenant-id", "realcorp.test"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q: %s", secret, data)
+		}
+	}
+
+	// replay without the server, the tenant ID and the query order differ from the recording
+	replayer, err := msgraphtest.NewRecorder(cassette, msgraphtest.Replay, scrubbing...)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	if len(replayer.Interactions()) != len(recorder.Interactions()) {
+		t.Errorf("loaded %d interactions, want %d", len(replayer.Interactions()), len(recorder.Interactions()))
+	}
+	g, err = msgraph.NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL,
+		msgraph.ClientWithRoundTripper(replayer))
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() with Replay error = %v", err)
+	}
+	users, err := g.ListUsers(msgraph.ListWithOrderBy("displayName"), msgraph.ListWithPageSize(2))
+	if err != nil {
+		t.Fatalf("GraphClient.ListUsers() with Replay error = %v", err)
+	}
+	if len(users) != len(recordedUsers) || users[0].UserPrincipalName != "alice@contoso.test" || users[0].MobilePhone != msgraphtest.ScrubbedValue {
+		t.Errorf("GraphClient.ListUsers() with Replay = %v, want the scrubbed %v", users, recordedUsers)
+	}
+	user, err := g.GetUser(alice["id"].(string))
+	if err != nil || user.DisplayName != "Alice" {
+		t.Errorf("GraphClient.GetUser() with Replay = %v, %v, want Alice", user, err)
+	}
+
+	// every interaction is replayed once
+	if _, err := g.GetUser(alice["id"].(string)); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Errorf("GraphClient.GetUser() replayed twice error = %v, want no recorded interaction", err)
+	}
+}
+
+func TestRecorder_tokenExpiry(t *testing.T) {
+	// the token server issued the token two hours ago, it expired an hour ago: the clock of the replay is past
+	// the original expiry
+	issued := time.Now().Add(-2 * time.Hour)
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", issued.UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"token_type":"Bearer","access_token":"token","expires_on":"%d","not_before":"%d"}`,
+			issued.Add(time.Hour).Unix(), issued.Unix())
+	}))
+	defer tokenServer.Close()
+	cassette := filepath.Join(t.TempDir(), "token.json")
+
+	recorder, err := msgraphtest.NewRecorder(cassette, msgraphtest.Record)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	// Hint: the recorded token has expired already, hence the GraphClient fails while recording
+	if _, err := msgraph.NewGraphClientWithCustomEndpoint("tenant", "app", "secret", tokenServer.URL, tokenServer.URL,
+		msgraph.ClientWithRoundTripper(recorder)); err == nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() with an expired token error = nil, want error")
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Recorder.Save() error = %v", err)
+	}
+	if body := recorder.Interactions()[0].Response.Body; strings.Contains(body, "expires_on") || !strings.Contains(body, `"expires_in":"3600"`) {
+		t.Errorf("recorded token response = %v, want expires_in 3600 instead of expires_on", body)
+	}
+
+	replayer, err := msgraphtest.NewRecorder(cassette, msgraphtest.Replay)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	if _, err := msgraph.NewGraphClientWithCustomEndpoint("tenant", "app", "secret", tokenServer.URL, tokenServer.URL,
+		msgraph.ClientWithRoundTripper(replayer)); err != nil {
+		t.Errorf("NewGraphClientWithCustomEndpoint() with Replay error = %v, want the replayed token to be valid", err)
+	}
+}
+
+func TestNewRecorder_missingCassette(t *testing.T) {
+	if _, err := msgraphtest.NewRecorder(filepath.Join(t.TempDir(), "missing.json"), msgraphtest.Replay); err == nil {
+		t.Errorf("NewRecorder() of a missing cassette error = nil, want error")
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	a, _ := url.ParseQuery("$top=2&$filter=displayName%20eq%20'Alice'&$select=id,displayName")
+	b, _ := url.ParseQuery("$select=id%2CdisplayName&$filter=displayName+eq+'Alice'&$top=2")
+	if msgraphtest.NormalizeQuery(a) != msgraphtest.NormalizeQuery(b) {
+		t.Errorf("NormalizeQuery() = %v and %v, want equal", msgraphtest.NormalizeQuery(a), msgraphtest.NormalizeQuery(b))
+	}
+}
@@ -0,0 +1,723 @@
+package msgraphtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiVersions are the API versions served, the same Objects are returned by all of them
+var apiVersions = map[string]bool{"v1.0": true, "beta": true}
+
+// route is a resolved request path
+type route struct {
+	collection string // the path of the collection, e.g. "users" or "users/<id>/calendars"
+	id         string // the ID of the requested Object, empty for the collection
+	parent     string // the ID of the parent of nested collections, e.g. the calendar of events, empty for all
+	members    string // the ID of the group whose members are requested
+	delta      bool   // a delta query of the collection
+	window     bool   // a calendarView, filtered by startDateTime and endDateTime
+}
+
+// supportedTimeZones are returned by /users/<id>/outlook/supportedTimeZones, a subset of the time zones of Windows
+var supportedTimeZones = []Object{
+	{"alias": "UTC", "displayName": "(UTC) Coordinated Universal Time"},
+	{"alias": "GMT Standard Time", "displayName": "(UTC+00:00) Dublin, Edinburgh, Lisbon, London"},
+	{"alias": "W. Europe Standard Time", "displayName": "(UTC+01:00) Amsterdam, Berlin, Bern, Rome, Stockholm, Vienna"},
+	{"alias": "Eastern Standard Time", "displayName": "(UTC-05:00) Eastern Time (US & Canada)"},
+	{"alias": "Pacific Standard Time", "displayName": "(UTC-08:00) Pacific Time (US & Canada)"},
+	{"alias": "Tokyo Standard Time", "displayName": "(UTC+09:00) Osaka, Sapporo, Tokyo"},
+}
+
+// nestedCollections are stored with their grandparent and the parent ID, e.g. the events of all calendars
+// of a user are stored as events of the user, hence they are also found by /users/<id>/events/<id>
+var nestedCollections = map[string]string{"calendars/events": "events", "calendargroups/calendars": "calendars"}
+
+// serveHTTP handles all requests, see Server
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("request-id", fmt.Sprintf("%x", time.Now().UnixNano()))
+	if id := r.Header.Get("client-request-id"); id != "" {
+		w.Header().Set("client-request-id", id)
+	}
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	isToken := strings.Contains(r.URL.Path, "/oauth2/")
+	path := r.URL.Path
+	if !isToken && apiVersions[segments[0]] {
+		path = "/" + strings.Join(segments[1:], "/")
+	}
+	if s.applyFault(w, r, path, isToken) {
+		return
+	}
+
+	switch {
+	case isToken:
+		s.serveToken(w, r)
+	case !apiVersions[segments[0]] || len(segments) < 2:
+		writeError(w, http.StatusNotFound, "Request_BadRequest", fmt.Sprintf("Invalid version or resource %v", r.URL.Path))
+	case !s.isAuthorized(r):
+		writeError(w, http.StatusUnauthorized, "InvalidAuthenticationToken", "Access token is empty or invalid.")
+	case len(segments) == 2 && segments[1] == "$batch":
+		s.serveBatch(w, r, segments[0])
+	default:
+		s.serveAPI(w, r, segments[1:])
+	}
+}
+
+// applyFault applies the first matching Fault, returns true if the request has been answered
+func (s *Server) applyFault(w http.ResponseWriter, r *http.Request, path string, isToken bool) bool {
+	s.lock.Lock()
+	var fault Fault
+	for _, f := range s.faults {
+		if (f.Method != "" && f.Method != r.Method) || (f.Count > 0 && f.hits >= f.Count) {
+			continue
+		}
+		if (f.Path == "" && isToken) || !strings.HasPrefix(path, f.Path) {
+			continue
+		}
+		f.hits++
+		fault = *f
+		break
+	}
+	s.lock.Unlock()
+
+	if fault.Latency > 0 {
+		timer := time.NewTimer(fault.Latency)
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+			return true
+		}
+	}
+	if fault.StatusCode == 0 {
+		return false
+	}
+	if fault.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Round(time.Second)/time.Second)))
+	}
+	code := map[int]string{http.StatusTooManyRequests: "TooManyRequests", http.StatusServiceUnavailable: "ServiceUnavailable",
+		http.StatusGatewayTimeout: "GatewayTimeout"}[fault.StatusCode]
+	if code == "" {
+		code = "InternalServerError"
+	}
+	writeError(w, fault.StatusCode, code, "Injected fault")
+	return true
+}
+
+// serveToken emulates the token endpoints of Azure AD and of managed identities
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, Object{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+	if segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/"); s.TenantID != "" && len(segments) > 1 &&
+		segments[1] == "oauth2" && segments[0] != s.TenantID {
+		writeJSON(w, http.StatusBadRequest, Object{"error": "invalid_request",
+			"error_description": fmt.Sprintf("AADSTS90002: Tenant '%v' not found.", segments[0])})
+		return
+	}
+	if clientID := r.PostForm.Get("client_id"); s.ClientID != "" && clientID != "" && clientID != s.ClientID {
+		writeJSON(w, http.StatusBadRequest, Object{"error": "unauthorized_client",
+			"error_description": fmt.Sprintf("AADSTS700016: Application with identifier '%v' was not found.", clientID)})
+		return
+	}
+	for _, scope := range strings.Fields(r.PostForm.Get("scope")) {
+		if !strings.HasSuffix(scope, "/.default") {
+			continue // Hint: delegated permissions like User.Read are not validated
+		}
+		if u, err := url.Parse(strings.TrimSuffix(scope, "/.default")); err != nil || u.Scheme == "" || u.Host == "" {
+			writeJSON(w, http.StatusBadRequest, Object{"error": "invalid_scope",
+				"error_description": fmt.Sprintf("AADSTS70011: The provided value for the input parameter 'scope' is not valid. The scope %v is not valid.", scope)})
+			return
+		}
+	}
+	if s.ClientSecret != "" && r.PostForm.Get("client_secret") != "" && r.PostForm.Get("client_secret") != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, Object{"error": "invalid_client",
+			"error_description": "AADSTS7000215: Invalid client secret provided."})
+		return
+	}
+	lifetime := s.TokenLifetime
+	if lifetime == 0 {
+		lifetime = time.Hour
+	}
+
+	s.lock.Lock()
+	token := fmt.Sprintf("msgraphtest-token-%d", len(s.tokens)+1)
+	s.tokens[token] = true
+	s.lock.Unlock()
+
+	res := Object{
+		"token_type":     "Bearer",
+		"expires_in":     int64(lifetime / time.Second),
+		"ext_expires_in": int64(lifetime / time.Second),
+		"access_token":   token,
+	}
+	if strings.Contains(r.URL.Path, "/oauth2/token") { // v1 endpoint and managed identities return timestamps
+		res["expires_on"] = strconv.FormatInt(time.Now().Add(lifetime).Unix(), 10)
+		res["not_before"] = strconv.FormatInt(time.Now().Unix(), 10)
+		res["resource"] = r.FormValue("resource")
+	}
+	if r.PostForm.Get("grant_type") == "refresh_token" {
+		res["refresh_token"] = token + "-refresh"
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// isAuthorized returns true if the request contains a token issued by the Server
+func (s *Server) isAuthorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.tokens[token]
+}
+
+// serveAPI handles an API-call, segments is the path without API version
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request, segments []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	rt, ok := s.resolve(segments)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Request_ResourceNotFound",
+			fmt.Sprintf("Resource '%v' does not exist or one of its queried reference-property objects are not present.", segments[len(segments)-1]))
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(rt.collection, "/outlook/supportedtimezones") && rt.id == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, Object{"value": supportedTimeZones})
+	case rt.delta && r.Method == http.MethodGet:
+		s.serveDelta(w, r, rt)
+	case rt.id != "" && r.Method == http.MethodGet:
+		obj, err := selectProperties(s.find(rt.collection, rt.id).obj, r.URL.Query().Get("$select"), !isDirectory(rt.collection))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, obj)
+	case rt.id != "" && r.Method == http.MethodPatch:
+		var patch Object
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			writeError(w, http.StatusBadRequest, "BadRequest", "Invalid JSON: "+err.Error())
+			return
+		}
+		e := s.find(rt.collection, rt.id)
+		if !matchesIfMatch(r, e.obj) {
+			writePreconditionFailed(w)
+			return
+		}
+		for _, property := range readOnlyProperties {
+			if _, ok := patch[property]; ok {
+				writeError(w, http.StatusBadRequest, "BadRequest",
+					fmt.Sprintf("Property '%v' is read-only and cannot be updated", property))
+				return
+			}
+		}
+		for key, value := range patch {
+			e.obj[key] = value
+		}
+		if _, ok := e.obj["lastModifiedDateTime"]; ok {
+			e.obj["lastModifiedDateTime"] = time.Now().UTC().Format(time.RFC3339Nano)
+		}
+		s.setETag(rt, e.obj)
+		e.seq = s.nextSeq()
+		w.WriteHeader(http.StatusNoContent)
+	case rt.id != "" && r.Method == http.MethodDelete:
+		if !matchesIfMatch(r, s.find(rt.collection, rt.id).obj) {
+			writePreconditionFailed(w)
+			return
+		}
+		s.remove(rt.collection, rt.id)
+		w.WriteHeader(http.StatusNoContent)
+	case rt.id == "" && r.Method == http.MethodGet:
+		s.serveList(w, r, rt)
+	case rt.id == "" && r.Method == http.MethodPost && rt.members == "":
+		var obj Object
+		if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+			writeError(w, http.StatusBadRequest, "BadRequest", "Invalid JSON: "+err.Error())
+			return
+		}
+		if upn, _ := obj["userPrincipalName"].(string); upn != "" && rt.collection == "users" && s.find("users", upn) != nil {
+			writeError(w, http.StatusBadRequest, "Request_BadRequest",
+				"Another object with the same value for property userPrincipalName already exists.")
+			return
+		}
+		if _, err := selectProperties(obj, r.URL.Query().Get("$select"), false); err != nil {
+			writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+			return
+		}
+		delete(obj, "passwordProfile") // Hint: never returned by the ms graph API
+		created, _ := selectProperties(s.create(rt, obj), r.URL.Query().Get("$select"), !isDirectory(rt.collection))
+		writeJSON(w, http.StatusCreated, created)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Request_BadRequest", fmt.Sprintf("Method %v is not supported", r.Method))
+	}
+}
+
+// serveList returns a page of a collection with the query options applied
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request, rt route) {
+	query := r.URL.Query()
+	if query.Get("$search") != "" && r.Header.Get("ConsistencyLevel") != "eventual" {
+		writeError(w, http.StatusBadRequest, "Request_UnsupportedQuery",
+			"Request with $search query parameter only works through MSGraph with a special request header: 'ConsistencyLevel: eventual'")
+		return
+	}
+	var objects []Object
+	for _, e := range s.list(rt) {
+		if rt.window && !inWindow(e.obj, query.Get("startDateTime"), query.Get("endDateTime")) {
+			continue
+		}
+		objects = append(objects, e.obj)
+	}
+	objects, err := applyQuery(objects, query, !isDirectory(rt.collection))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Request_UnsupportedQuery", err.Error())
+		return
+	}
+
+	res := Object{}
+	if query.Get("$count") == "true" {
+		res["@odata.count"] = len(objects)
+	}
+	page, nextLink := s.page(r, objects, pageSize(r))
+	res["value"] = page
+	if nextLink != "" {
+		res["@odata.nextLink"] = nextLink
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// serveDelta returns the changes of a collection since the $deltatoken, all Objects without token
+func (s *Server) serveDelta(w http.ResponseWriter, r *http.Request, rt route) {
+	query := r.URL.Query()
+	since, _ := strconv.ParseInt(query.Get("$deltatoken"), 10, 64)
+	var objects []Object
+	for _, e := range s.list(rt) {
+		if e.seq <= since || (rt.window && !inWindow(e.obj, query.Get("startDateTime"), query.Get("endDateTime"))) {
+			continue
+		}
+		obj := copyObject(e.obj)
+		if rt.collection == "groups" {
+			var members []interface{}
+			for _, id := range s.members[e.obj["id"].(string)] {
+				members = append(members, Object{"@odata.type": "#microsoft.graph.user", "id": id})
+			}
+			obj["members@delta"] = members
+		}
+		objects = append(objects, obj)
+	}
+	if since > 0 {
+		for _, t := range s.collections[rt.collection].removed {
+			if t.seq > since && (rt.parent == "" || rt.parent == t.parent) {
+				objects = append(objects, Object{"id": t.id, "@removed": Object{"reason": "deleted"}})
+			}
+		}
+	}
+
+	size := pageSize(r)
+	if prefer := r.Header.Get("Prefer"); strings.HasPrefix(prefer, "odata.maxpagesize=") {
+		size, _ = strconv.Atoi(strings.TrimPrefix(prefer, "odata.maxpagesize="))
+	}
+	page, nextLink := s.page(r, objects, size)
+	res := Object{"value": page}
+	if nextLink != "" {
+		res["@odata.nextLink"] = nextLink
+	} else {
+		deltaLink := *r.URL
+		query.Del("$skiptoken")
+		query.Set("$deltatoken", strconv.FormatInt(s.seq, 10))
+		deltaLink.RawQuery = query.Encode()
+		res["@odata.deltaLink"] = s.URL + deltaLink.RequestURI()
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// pageSize returns the $top of the request, 100 if not set
+func pageSize(r *http.Request) int {
+	if top, err := strconv.Atoi(r.URL.Query().Get("$top")); err == nil && top > 0 {
+		return top
+	}
+	return 100
+}
+
+// page returns the page of the given Objects selected by the $skiptoken of the request and the nextLink
+// to the following page, empty if it is the last one
+func (s *Server) page(r *http.Request, objects []Object, size int) ([]Object, string) {
+	if size <= 0 {
+		size = 100
+	}
+	query := r.URL.Query()
+	skip, _ := strconv.Atoi(query.Get("$skiptoken"))
+	if skip > len(objects) {
+		skip = len(objects)
+	}
+	end := skip + size
+	if end >= len(objects) {
+		return nonNil(objects[skip:]), ""
+	}
+	next := *r.URL
+	query.Set("$skiptoken", strconv.Itoa(end))
+	next.RawQuery = query.Encode()
+	return objects[skip:end], s.URL + next.RequestURI()
+}
+
+// nonNil returns an empty slice instead of nil, hence the JSON value is [] instead of null
+func nonNil(objects []Object) []Object {
+	if objects == nil {
+		return []Object{}
+	}
+	return objects
+}
+
+// serveBatch handles a JSON $batch request by handling all of its requests in order
+func (s *Server) serveBatch(w http.ResponseWriter, r *http.Request, version string) {
+	var batch struct {
+		Requests []struct {
+			ID      string            `json:"id"`
+			Method  string            `json:"method"`
+			URL     string            `json:"url"`
+			Headers map[string]string `json:"headers"`
+			Body    json.RawMessage   `json:"body"`
+		} `json:"requests"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", "Invalid batch payload: "+err.Error())
+		return
+	}
+	if len(batch.Requests) > 20 {
+		writeError(w, http.StatusBadRequest, "BadRequest", "Number of batch request steps exceeds the maximum of 20.")
+		return
+	}
+
+	responses := make([]Object, 0, len(batch.Requests))
+	for _, item := range batch.Requests {
+		var body io.Reader
+		if len(item.Body) > 0 {
+			body = bytes.NewReader(item.Body)
+		}
+		req := httptest.NewRequest(item.Method, "/"+version+"/"+strings.TrimPrefix(item.URL, "/"), body).WithContext(r.Context())
+		for key, value := range item.Headers {
+			req.Header.Set(key, value)
+		}
+		req.Header.Set("Authorization", r.Header.Get("Authorization"))
+
+		rec := httptest.NewRecorder()
+		s.serveAPI(rec, req, strings.Split(strings.Trim(req.URL.Path, "/"), "/")[1:])
+		res := Object{"id": item.ID, "status": rec.Code, "headers": Object{"Content-Type": rec.Header().Get("Content-Type")}}
+		if rec.Body.Len() > 0 {
+			res["body"] = json.RawMessage(rec.Body.Bytes())
+		}
+		responses = append(responses, res)
+	}
+	writeJSON(w, http.StatusOK, Object{"responses": responses})
+}
+
+// resolve returns the route of the given path segments without API version, false if any Object in the
+// path does not exist. The caller must hold s.lock.
+func (s *Server) resolve(segments []string) (route, bool) {
+	var rt route
+	for i := 0; i < len(segments); i++ {
+		name := strings.ToLower(segments[i])
+		if name == "" {
+			return rt, false
+		}
+		if rt.collection != "" && rt.id == "" && rt.members == "" { // an Object of the collection or delta
+			if name == "delta" && i == len(segments)-1 {
+				rt.delta = true
+				return rt, true
+			}
+			e := s.find(rt.collection, segments[i])
+			if e == nil {
+				return rt, false
+			}
+			rt.id = e.obj["id"].(string)
+			continue
+		}
+		if rt.members != "" {
+			return rt, false // Hint: references of members are not supported
+		}
+
+		// a collection of the current Object or the root
+		switch {
+		case (name == "outlook" || name == "security") && i+1 < len(segments):
+			i++
+			name += "/" + strings.ToLower(segments[i])
+		case name == "calendar" && i+1 < len(segments): // the default calendar contains all events
+			i++
+			rt.window = strings.ToLower(segments[i]) == "calendarview"
+			name = "events"
+		case name == "calendarview":
+			rt.window = true
+			name = "events"
+		}
+		prefix := rt.collection
+		if rt.id != "" {
+			prefix += "/" + rt.id
+		}
+		if prefix == "" {
+			rt = route{collection: name, window: rt.window}
+			continue
+		}
+		parentCollection := rt.collection[strings.LastIndex(rt.collection, "/")+1:]
+		if nested, ok := nestedCollections[parentCollection+"/"+name]; ok {
+			rt = route{collection: strings.TrimSuffix(rt.collection, "/"+parentCollection) + "/" + nested, parent: rt.id}
+			continue
+		}
+		if rt.collection == "groups" && name == "members" {
+			rt = route{collection: "users", members: rt.id}
+			continue
+		}
+		rt = route{collection: prefix + "/" + name, window: rt.window}
+	}
+	return rt, true
+}
+
+// isDirectory returns true if the collection with the given path contains directory objects, e.g. users
+func isDirectory(collectionPath string) bool {
+	return collectionPath == "users" || collectionPath == "groups"
+}
+
+// find returns the entry of the collection with the given path whose ID, userPrincipalName or mail is the
+// given key, nil if there is none. The caller must hold s.lock.
+func (s *Server) find(collectionPath, key string) *entry {
+	c := s.collections[collectionPath]
+	if c == nil {
+		return nil
+	}
+	for _, e := range c.entries {
+		if e.obj["id"] == key {
+			return e
+		}
+	}
+	for _, e := range c.entries {
+		for _, property := range []string{"userPrincipalName", "mail"} {
+			if value, _ := e.obj[property].(string); value != "" && strings.EqualFold(value, key) {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// list returns the entries of the route. The caller must hold s.lock.
+func (s *Server) list(rt route) []*entry {
+	if rt.members != "" {
+		var res []*entry
+		for _, id := range s.members[rt.members] {
+			if e := s.find("users", id); e != nil {
+				res = append(res, e)
+			}
+		}
+		return res
+	}
+	c := s.collections[rt.collection]
+	if c == nil {
+		return nil
+	}
+	var res []*entry
+	for _, e := range c.entries {
+		if rt.parent == "" || e.parent == rt.parent {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// create stores a copy of the given Object in the collection of the route and returns a copy of it. The
+// caller must hold s.lock.
+func (s *Server) create(rt route, obj Object) Object {
+	obj = copyObject(obj)
+	if obj == nil {
+		obj = Object{}
+	}
+	if id, _ := obj["id"].(string); id == "" {
+		obj["id"] = s.newID()
+	}
+	s.setDefaults(rt, obj)
+	s.setETag(rt, obj)
+	c := s.collections[rt.collection]
+	if c == nil {
+		c = &collection{}
+		s.collections[rt.collection] = c
+	}
+	c.entries = append(c.entries, &entry{obj: obj, seq: s.nextSeq(), parent: rt.parent})
+	return copyObject(obj)
+}
+
+// setDefaults sets the properties the ms graph API sets on creation, e.g. the owner of a calendar. The
+// caller must hold s.lock.
+func (s *Server) setDefaults(rt route, obj Object) {
+	if isDirectory(rt.collection) {
+		if _, ok := obj["createdDateTime"]; !ok {
+			obj["createdDateTime"] = time.Now().UTC().Format(time.RFC3339)
+		}
+		return
+	}
+	segments := strings.Split(rt.collection, "/")
+	if len(segments) != 3 || segments[0] != "users" {
+		return
+	}
+	user := s.find("users", segments[1])
+	if user == nil {
+		return
+	}
+	address, _ := user.obj["mail"].(string)
+	if address == "" {
+		address, _ = user.obj["userPrincipalName"].(string)
+	}
+	emailAddress := Object{"name": user.obj["displayName"], "address": address}
+	switch segments[2] {
+	case "calendars":
+		if _, ok := obj["owner"]; !ok {
+			obj["owner"] = emailAddress
+		}
+	case "events":
+		if _, ok := obj["organizer"]; !ok {
+			obj["organizer"] = Object{"emailAddress": emailAddress}
+		}
+		now := time.Now().UTC().Format(time.RFC3339Nano)
+		for key, value := range map[string]interface{}{
+			"createdDateTime":       now,
+			"lastModifiedDateTime":  now,
+			"originalStartTimeZone": timeZone(obj["start"]),
+			"originalEndTimeZone":   timeZone(obj["end"]),
+			"isAllDay":              false,
+			"isCancelled":           false,
+		} {
+			if _, ok := obj[key]; !ok {
+				obj[key] = value
+			}
+		}
+	}
+}
+
+// readOnlyProperties are maintained by the Server, updates of them are rejected with 400 Bad Request like the
+// ms graph API does
+var readOnlyProperties = []string{"@odata.etag", "changeKey"}
+
+// changeKeyCollections are the collections whose Objects have a changeKey besides their @odata.etag
+var changeKeyCollections = map[string]bool{"events": true, "calendars": true, "calendargroups": true}
+
+// setETag sets a new @odata.etag of the given Object of the route, and its changeKey if it has one. Directory
+// objects like users and groups have none. The caller must hold s.lock.
+func (s *Server) setETag(rt route, obj Object) {
+	if isDirectory(rt.collection) {
+		return
+	}
+	s.changes++
+	obj["@odata.etag"] = fmt.Sprintf(`W/"DwAAABYAAAA%08d"`, s.changes)
+	if changeKeyCollections[rt.collection[strings.LastIndex(rt.collection, "/")+1:]] {
+		obj["changeKey"] = fmt.Sprintf("AAAAAAAAAAAAAAAAAAAA%08d", s.changes)
+	}
+}
+
+// matchesIfMatch returns true if the request has no If-Match header, if it is "*" or if it contains the
+// @odata.etag of the given Object
+func matchesIfMatch(r *http.Request, obj Object) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" || ifMatch == "*" {
+		return true
+	}
+	etag, _ := obj["@odata.etag"].(string)
+	for _, value := range strings.Split(ifMatch, ",") {
+		if etag != "" && strings.TrimSpace(value) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writePreconditionFailed writes the error of the ms graph API for an outdated If-Match header
+func writePreconditionFailed(w http.ResponseWriter) {
+	writeError(w, http.StatusPreconditionFailed, "ErrorIrresolvableConflict",
+		"The send or update operation could not be performed because the change key passed in the request does not match the current change key for the item.")
+}
+
+// timeZone returns the timeZone of the given dateTimeTimeZone, UTC if it has none
+func timeZone(dateTimeTimeZone interface{}) string {
+	m, _ := dateTimeTimeZone.(map[string]interface{})
+	if tz, _ := m["timeZone"].(string); tz != "" {
+		return tz
+	}
+	return "UTC"
+}
+
+// remove deletes the Object with the given ID from the collection with the given path, including its
+// nested collections and group memberships. The caller must hold s.lock.
+func (s *Server) remove(collectionPath, id string) {
+	c := s.collections[collectionPath]
+	for i, e := range c.entries {
+		if e.obj["id"] == id {
+			c.entries = append(c.entries[:i], c.entries[i+1:]...)
+			c.removed = append(c.removed, tombstone{id: id, seq: s.nextSeq(), parent: e.parent})
+			break
+		}
+	}
+	for path := range s.collections {
+		if strings.HasPrefix(path, collectionPath+"/"+id+"/") {
+			delete(s.collections, path)
+		}
+	}
+	delete(s.members, id)
+	for group, members := range s.members {
+		for i, member := range members {
+			if member == id {
+				s.members[group] = append(members[:i:i], members[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// writeJSON writes the given value as JSON response with the given StatusCode
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json;odata.metadata=minimal")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error response of the ms graph API
+func writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	writeJSON(w, statusCode, Object{"error": Object{
+		"code":    code,
+		"message": message,
+		"innerError": Object{
+			"date":       time.Now().UTC().Format(time.RFC3339),
+			"request-id": w.Header().Get("request-id"),
+		},
+	}})
+}
+
+// inWindow returns true if the event overlaps the window of a calendarView. Events whose start or end
+// cannot be parsed are always included.
+func inWindow(event Object, start, end string) bool {
+	windowStart, err1 := parseDateTime(start)
+	windowEnd, err2 := parseDateTime(end)
+	eventStart, err3 := parseDateTime(dateTimeOf(event["start"]))
+	eventEnd, err4 := parseDateTime(dateTimeOf(event["end"]))
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return true
+	}
+	return eventStart.Before(windowEnd) && eventEnd.After(windowStart)
+}
+
+// dateTimeOf returns the dateTime of a dateTimeTimeZone value
+func dateTimeOf(v interface{}) string {
+	obj, _ := v.(map[string]interface{})
+	dateTime, _ := obj["dateTime"].(string)
+	return dateTime
+}
+
+// parseDateTime parses a dateTime of the ms graph API with or without time zone offset
+func parseDateTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02T15:04:05.9999999", value)
+}
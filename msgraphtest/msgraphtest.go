@@ -0,0 +1,235 @@
+// Package msgraphtest provides an in-memory fake of the ms graph API for tests without network. The Server
+// emulates the token endpoint and the users, groups, members, calendars, calendarGroups, events, outlook
+// categories, subscriptions and security endpoints used by the msgraph package, including paging with
+// @odata.nextLink, basic $filter, $search, $select, $orderby and $count support, delta queries and $batch.
+// Outlook resources like events have an @odata.etag and updates with an outdated If-Match header fail with
+// 412 Precondition Failed, updates of their @odata.etag or changeKey with 400 Bad Request.
+//
+//	srv := msgraphtest.NewServer()
+//	defer srv.Close()
+//	alice := srv.AddUser(msgraphtest.Object{"displayName": "Alice", "userPrincipalName": "alice@contoso.test"})
+//	srv.InjectFault(msgraphtest.Fault{Path: "/users", StatusCode: http.StatusTooManyRequests, Count: 1})
+//
+//	graphClient, err := msgraph.NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL)
+//	user, err := graphClient.GetUser(alice["id"].(string))
+//
+// The package does not depend on the msgraph package, hence it can be used by the tests of msgraph itself.
+// Use ObjectFrom to seed the Server with the typed models of msgraph.
+//
+// Alternatively, the Recorder records the API-calls against a real tenant to a cassette file once and
+// replays them deterministically afterwards, see NewRecorder.
+package msgraphtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Object is a resource of the fake ms graph API, e.g. a user, as decoded JSON object
+type Object map[string]interface{}
+
+// ObjectFrom returns the JSON representation of the given value as Object, e.g. of a msgraph.User.
+// Panics if the value cannot be marshalled to a JSON object.
+func ObjectFrom(v interface{}) Object {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("msgraphtest: cannot marshal %T: %v", v, err))
+	}
+	var obj Object
+	if err := json.Unmarshal(data, &obj); err != nil {
+		panic(fmt.Sprintf("msgraphtest: %T is not a JSON object: %v", v, err))
+	}
+	return obj
+}
+
+// Fault makes the Server fail or delay matching requests, e.g. to test retries and timeouts
+type Fault struct {
+	Method     string        // the HTTP method of the affected requests, all methods if empty
+	Path       string        // the prefix of the affected paths without API version, e.g. "/users", all API-calls if empty
+	StatusCode int           // the StatusCode returned instead of the response, e.g. 429 or 500, none if 0
+	RetryAfter time.Duration // the Retry-After header of the error response, omitted if 0
+	Latency    time.Duration // the delay before the request is handled
+	Count      int           // the number of requests affected, all if 0
+
+	hits int
+}
+
+// entry is a stored Object with the sequence number of its last change, used for delta queries
+type entry struct {
+	obj    Object
+	seq    int64
+	parent string // the ID of the parent of nested collections, e.g. the calendar of an event
+}
+
+// tombstone records a removed Object for delta queries
+type tombstone struct {
+	id     string
+	seq    int64
+	parent string
+}
+
+// collection contains the Objects of a collection, e.g. all users or the calendars of a user
+type collection struct {
+	entries []*entry
+	removed []tombstone
+}
+
+// Server is a fake of the ms graph API and its token endpoint. Use its URL as both, the Azure AD
+// authentication endpoint and the service root endpoint of the GraphClient. The zero value is not
+// usable, use NewServer.
+type Server struct {
+	*httptest.Server
+
+	// TenantID, if not empty, is the only tenant known by the token endpoint, other tenants are rejected
+	TenantID string
+	// ClientID, if not empty, is the only application ID known by the token endpoint, others are rejected
+	ClientID string
+	// ClientSecret, if not empty, is the only client secret accepted by the token endpoint, hence
+	// other secrets are rejected like invalid credentials. Any credentials are accepted if empty.
+	ClientSecret string
+	// TokenLifetime is the validity of the issued tokens, one hour if 0
+	TokenLifetime time.Duration
+
+	lock        sync.Mutex
+	seq         int64
+	ids         int
+	changes     int                    // the number of @odata.etag changes, used for unique change keys
+	collections map[string]*collection // key is the path of the collection, e.g. "users/<id>/calendars"
+	members     map[string][]string    // the member IDs of the groups by group ID
+	tokens      map[string]bool        // the access tokens issued
+	faults      []*Fault
+}
+
+// NewServer starts and returns a new Server without any Objects. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		collections: make(map[string]*collection),
+		members:     make(map[string][]string),
+		tokens:      make(map[string]bool),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// InjectFault adds the given Fault, it affects the matching requests until its Count is exhausted or
+// ClearFaults is called. The first matching Fault is applied.
+func (s *Server) InjectFault(fault Fault) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults removes all Faults
+func (s *Server) ClearFaults() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.faults = nil
+}
+
+// Add stores a copy of the given Object in the collection with the given path, e.g. "users",
+// "users/<id>/outlook/masterCategories" or "security/alerts", and returns the copy. An ID is generated
+// if the Object has none.
+func (s *Server) Add(collectionPath string, obj Object) Object {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	r, ok := s.resolve(strings.Split(strings.Trim(collectionPath, "/"), "/"))
+	if !ok || r.id != "" || r.delta || r.members != "" {
+		panic(fmt.Sprintf("msgraphtest: %v is not a collection", collectionPath))
+	}
+	return s.create(r, obj)
+}
+
+// AddUser stores the given user and returns it with its ID
+func (s *Server) AddUser(user Object) Object {
+	return s.Add("users", user)
+}
+
+// AddGroup stores the given group and returns it with its ID
+func (s *Server) AddGroup(group Object) Object {
+	return s.Add("groups", group)
+}
+
+// AddMember adds the user or group with the given ID to the members of the group with the given ID
+func (s *Server) AddMember(groupID, memberID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.members[groupID] = append(s.members[groupID], memberID)
+	if e := s.find("groups", groupID); e != nil {
+		e.seq = s.nextSeq()
+	}
+}
+
+// AddCalendar stores the given calendar of the user with the given ID or userPrincipalName
+func (s *Server) AddCalendar(userID string, calendar Object) Object {
+	return s.Add("users/"+userID+"/calendars", calendar)
+}
+
+// AddCalendarGroup stores the given calendar group of the user with the given ID or userPrincipalName
+func (s *Server) AddCalendarGroup(userID string, calendarGroup Object) Object {
+	return s.Add("users/"+userID+"/calendarGroups", calendarGroup)
+}
+
+// AddEvent stores the given event in the calendar with the given ID of the user with the given ID or
+// userPrincipalName. The event is stored in the default calendar if calendarID is empty.
+func (s *Server) AddEvent(userID, calendarID string, event Object) Object {
+	if calendarID == "" {
+		return s.Add("users/"+userID+"/events", event)
+	}
+	return s.Add("users/"+userID+"/calendars/"+calendarID+"/events", event)
+}
+
+// AddCategory stores the given outlook category of the user with the given ID or userPrincipalName
+func (s *Server) AddCategory(userID string, category Object) Object {
+	return s.Add("users/"+userID+"/outlook/masterCategories", category)
+}
+
+// Get returns a copy of the Object with the given ID of the collection with the given path, see Add
+func (s *Server) Get(collectionPath, id string) (Object, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	r, ok := s.resolve(strings.Split(strings.Trim(collectionPath, "/")+"/"+id, "/"))
+	if !ok || r.id == "" {
+		return nil, false
+	}
+	return copyObject(s.find(r.collection, r.id).obj), true
+}
+
+// List returns copies of all Objects of the collection with the given path, see Add
+func (s *Server) List(collectionPath string) []Object {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	r, ok := s.resolve(strings.Split(strings.Trim(collectionPath, "/"), "/"))
+	if !ok {
+		return nil
+	}
+	var res []Object
+	for _, e := range s.list(r) {
+		res = append(res, copyObject(e.obj))
+	}
+	return res
+}
+
+// nextSeq returns the next sequence number of a change. The caller must hold s.lock.
+func (s *Server) nextSeq() int64 {
+	s.seq++
+	return s.seq
+}
+
+// newID returns a new unique ID formatted as GUID. The caller must hold s.lock.
+func (s *Server) newID() string {
+	s.ids++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", s.ids)
+}
+
+// copyObject returns a deep copy of the given Object
+func copyObject(obj Object) Object {
+	data, _ := json.Marshal(obj)
+	var res Object
+	_ = json.Unmarshal(data, &res)
+	return res
+}
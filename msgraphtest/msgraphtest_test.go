@@ -0,0 +1,176 @@
+package msgraphtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	msgraph "github.com/SerenityITS-Development/go-msgraph"
+	"github.com/SerenityITS-Development/go-msgraph/msgraphtest"
+)
+
+// newTestClient returns a new Server and a GraphClient using it, both are closed when the test finishes
+func newTestClient(t *testing.T, opts ...msgraph.GraphClientOption) (*msgraphtest.Server, *msgraph.GraphClient) {
+	t.Helper()
+	srv := msgraphtest.NewServer()
+	t.Cleanup(srv.Close)
+	g, err := msgraph.NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL, opts...)
+	if err != nil {
+		t.Fatalf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+	return srv, g
+}
+
+func TestServer_paging(t *testing.T) {
+	srv, g := newTestClient(t)
+	for i := 0; i < 250; i++ {
+		srv.AddUser(msgraphtest.Object{"displayName": fmt.Sprintf("User %03d", i), "userPrincipalName": fmt.Sprintf("user%03d@contoso.test", i)})
+	}
+
+	users, err := g.ListUsers(msgraph.ListWithPageSize(40))
+	if err != nil {
+		t.Fatalf("GraphClient.ListUsers() error = %v", err)
+	}
+	if len(users) != 250 {
+		t.Errorf("GraphClient.ListUsers() returned %d users, want 250", len(users))
+	}
+	if users[249].UserPrincipalName != "user249@contoso.test" {
+		t.Errorf("last user = %v, want user249@contoso.test", users[249].UserPrincipalName)
+	}
+}
+
+func TestServer_query(t *testing.T) {
+	srv, g := newTestClient(t)
+	for _, name := range []string{"Bob", "Alice", "Alex"} {
+		srv.AddUser(msgraphtest.Object{"displayName": name, "userPrincipalName": name + "@contoso.test", "accountEnabled": name != "Alex"})
+	}
+
+	users, err := g.ListUsers(msgraph.ListWithFilter("startswith(displayName, 'al') and accountEnabled eq true"),
+		msgraph.ListWithSelect("displayName"))
+	if err != nil {
+		t.Fatalf("GraphClient.ListUsers() error = %v", err)
+	}
+	if len(users) != 1 || users[0].DisplayName != "Alice" || users[0].ID != "" || users[0].UserPrincipalName != "" {
+		t.Errorf("GraphClient.ListUsers() = %v, want only the displayName of Alice", users)
+	}
+
+	var count int
+	users, err = g.ListUsers(msgraph.ListWithOrderBy("displayName desc"), msgraph.ListWithCount(&count))
+	if err != nil {
+		t.Fatalf("GraphClient.ListUsers() error = %v", err)
+	}
+	if count != 3 || len(users) != 3 || users[0].DisplayName != "Bob" || users[2].DisplayName != "Alex" {
+		t.Errorf("GraphClient.ListUsers() = %v with count %d, want Bob, Alice, Alex", users, count)
+	}
+
+	_, err = g.ListUsers(msgraph.ListWithFilter("displayName in ('Alice')"))
+	var graphErr *msgraph.GraphError
+	if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusBadRequest || graphErr.Code != "Request_UnsupportedQuery" {
+		t.Errorf("GraphClient.ListUsers() with unsupported $filter error = %v, want Request_UnsupportedQuery", err)
+	}
+}
+
+func TestServer_InjectFault(t *testing.T) {
+	srv, g := newTestClient(t, msgraph.ClientWithRetryPolicy(msgraph.RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond}))
+	user := srv.AddUser(msgraphtest.Object{"displayName": "Alice", "userPrincipalName": "alice@contoso.test"})
+
+	srv.InjectFault(msgraphtest.Fault{Path: "/users", StatusCode: http.StatusTooManyRequests, Count: 2})
+	if _, err := g.GetUser(user["id"].(string)); err != nil {
+		t.Errorf("GraphClient.GetUser() error = %v, want success after retries", err)
+	}
+
+	srv.InjectFault(msgraphtest.Fault{Method: http.MethodGet, StatusCode: http.StatusServiceUnavailable})
+	_, err := g.GetUser(user["id"].(string))
+	var graphErr *msgraph.GraphError
+	if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusServiceUnavailable || graphErr.Retries != 2 {
+		t.Errorf("GraphClient.GetUser() error = %v, want 503 after 2 retries", err)
+	}
+	srv.ClearFaults()
+
+	srv.InjectFault(msgraphtest.Fault{Latency: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := g.GetUser(user["id"].(string), msgraph.GetWithContext(ctx)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GraphClient.GetUser() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestServer_delta(t *testing.T) {
+	srv, g := newTestClient(t)
+	alice := srv.AddUser(msgraphtest.Object{"displayName": "Alice", "userPrincipalName": "alice@contoso.test"})
+	srv.AddUser(msgraphtest.Object{"displayName": "Bob", "userPrincipalName": "bob@contoso.test"})
+
+	delta, err := g.ListUsersDelta()
+	if err != nil {
+		t.Fatalf("GraphClient.ListUsersDelta() error = %v", err)
+	}
+	if len(delta.Users) != 2 || delta.DeltaLink == "" {
+		t.Fatalf("GraphClient.ListUsersDelta() = %v, want 2 users and a DeltaLink", delta)
+	}
+
+	srv.AddUser(msgraphtest.Object{"displayName": "Carol", "userPrincipalName": "carol@contoso.test"})
+	user, err := g.GetUser(alice["id"].(string))
+	if err != nil {
+		t.Fatalf("GraphClient.GetUser() error = %v", err)
+	}
+	if err := user.DeleteUser(); err != nil {
+		t.Fatalf("User.DeleteUser() error = %v", err)
+	}
+
+	delta, err = g.ListUsersDelta(msgraph.ListWithDeltaLink(delta.DeltaLink))
+	if err != nil {
+		t.Fatalf("GraphClient.ListUsersDelta() error = %v", err)
+	}
+	if len(delta.Users) != 1 || delta.Users[0].DisplayName != "Carol" {
+		t.Errorf("GraphClient.ListUsersDelta() users = %v, want Carol", delta.Users)
+	}
+	if len(delta.Removed) != 1 || delta.Removed[0].ID != alice["id"] {
+		t.Errorf("GraphClient.ListUsersDelta() removed = %v, want Alice", delta.Removed)
+	}
+}
+
+func TestServer_batch(t *testing.T) {
+	srv, g := newTestClient(t)
+	alice := srv.AddUser(msgraphtest.Object{"displayName": "Alice", "userPrincipalName": "alice@contoso.test"})
+
+	var found, missing msgraph.User
+	batch := g.NewBatch()
+	foundItem := batch.GetUser("alice@contoso.test", &found)
+	missingItem := batch.GetUser("missing@contoso.test", &missing)
+	if err := batch.Execute(context.Background()); err != nil {
+		t.Fatalf("BatchRequest.Execute() error = %v", err)
+	}
+	if foundItem.Err() != nil || found.ID != alice["id"] {
+		t.Errorf("batched GetUser() = %v, %v, want Alice", found, foundItem.Err())
+	}
+	if !errors.Is(missingItem.Err(), msgraph.ErrNotFound) {
+		t.Errorf("batched GetUser() of a missing user error = %v, want ErrNotFound", missingItem.Err())
+	}
+}
+
+func TestServer_authentication(t *testing.T) {
+	srv := msgraphtest.NewServer()
+	defer srv.Close()
+	srv.TenantID, srv.ClientID, srv.ClientSecret = "tenant", "app", "secret"
+
+	if _, err := msgraph.NewGraphClientWithCustomEndpoint("tenant", "app", "secret", srv.URL, srv.URL); err != nil {
+		t.Errorf("NewGraphClientWithCustomEndpoint() error = %v", err)
+	}
+	for _, credentials := range [][3]string{{"other", "app", "secret"}, {"tenant", "other", "secret"}, {"tenant", "app", "other"}} {
+		if _, err := msgraph.NewGraphClientWithCustomEndpoint(credentials[0], credentials[1], credentials[2], srv.URL, srv.URL); err == nil {
+			t.Errorf("NewGraphClientWithCustomEndpoint(%v) error = nil, want invalid credentials", credentials)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/v1.0/users")
+	if err != nil {
+		t.Fatalf("http.Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("API-call without token returned %v, want 401", resp.Status)
+	}
+}
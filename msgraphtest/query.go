@@ -0,0 +1,188 @@
+package msgraphtest
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// applyQuery returns copies of the given Objects with $filter, $search, $orderby and $select applied, see
+// selectProperties for keepID
+func applyQuery(objects []Object, query url.Values, keepID bool) ([]Object, error) {
+	var err error
+	if filter := query.Get("$filter"); filter != "" {
+		if objects, err = filterObjects(objects, filter); err != nil {
+			return nil, err
+		}
+	}
+	if search := query.Get("$search"); search != "" {
+		if objects, err = searchObjects(objects, search); err != nil {
+			return nil, err
+		}
+	}
+	if orderBy := query.Get("$orderby"); orderBy != "" {
+		if err := sortObjects(objects, orderBy); err != nil {
+			return nil, err
+		}
+	}
+	res := make([]Object, 0, len(objects))
+	for _, obj := range objects {
+		selected, err := selectProperties(obj, query.Get("$select"), keepID)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, selected)
+	}
+	return res, nil
+}
+
+// selectProperties returns a copy of the given Object with only the properties of $select, all properties if
+// $select is empty. The id and @odata.etag are kept if keepID is true, like outlook resources do, while directory
+// objects like users and groups only return the id if selected.
+func selectProperties(obj Object, selectOption string, keepID bool) (Object, error) {
+	if selectOption == "" {
+		return copyObject(obj), nil
+	}
+	res := Object{}
+	if keepID {
+		res["id"] = obj["id"]
+		if etag, ok := obj["@odata.etag"]; ok {
+			res["@odata.etag"] = etag
+		}
+	}
+	for _, property := range strings.Split(selectOption, ",") {
+		property = strings.TrimSpace(property)
+		if property == "" {
+			return nil, fmt.Errorf("Invalid $select %q", selectOption)
+		}
+		if value, ok := obj[property]; ok {
+			res[property] = value
+		}
+	}
+	return copyObject(res), nil
+}
+
+var (
+	// comparisonPattern matches e.g. "displayName eq 'Alice'" or "accountEnabled ne false"
+	comparisonPattern = regexp.MustCompile(`^([\w/@.]+) (eq|ne) ('(?:[^']|'')*'|true|false|null|-?[\d.]+)$`)
+	// startsWithPattern matches e.g. "startswith(displayName, 'Al')"
+	startsWithPattern = regexp.MustCompile(`^startswith\(([\w/@.]+), *('(?:[^']|'')*')\)$`)
+	// andPattern splits the clauses of a $filter
+	andPattern = regexp.MustCompile(`(?i) and `)
+)
+
+// filterObjects returns the Objects matching the given $filter. Supported are comparisons with eq and ne and
+// startswith, joined by and. Other expressions return an error like unsupported queries of the ms graph API.
+func filterObjects(objects []Object, filter string) ([]Object, error) {
+	type clause struct {
+		property, operator string
+		value              interface{}
+	}
+	var clauses []clause
+	for _, part := range andPattern.Split(strings.TrimSpace(filter), -1) {
+		part = strings.TrimSpace(part)
+		if m := comparisonPattern.FindStringSubmatch(part); m != nil {
+			clauses = append(clauses, clause{property: m[1], operator: m[2], value: parseLiteral(m[3])})
+		} else if m := startsWithPattern.FindStringSubmatch(part); m != nil {
+			clauses = append(clauses, clause{property: m[1], operator: "startswith", value: parseLiteral(m[2])})
+		} else {
+			return nil, fmt.Errorf("Unsupported or invalid query filter clause specified: %q", part)
+		}
+	}
+
+	var res []Object
+	for _, obj := range objects {
+		matches := true
+		for _, c := range clauses {
+			value := property(obj, c.property)
+			switch c.operator {
+			case "eq":
+				matches = matches && equal(value, c.value)
+			case "ne":
+				matches = matches && !equal(value, c.value)
+			case "startswith":
+				s, _ := value.(string)
+				matches = matches && strings.HasPrefix(strings.ToLower(s), strings.ToLower(c.value.(string)))
+			}
+		}
+		if matches {
+			res = append(res, obj)
+		}
+	}
+	return res, nil
+}
+
+// parseLiteral returns the value of an OData literal
+func parseLiteral(literal string) interface{} {
+	switch {
+	case literal == "null":
+		return nil
+	case literal == "true" || literal == "false":
+		return literal == "true"
+	case strings.HasPrefix(literal, "'"):
+		return strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	}
+	f, _ := strconv.ParseFloat(literal, 64)
+	return f
+}
+
+// equal compares a property value with a literal, strings are compared case-insensitive like the ms graph API does
+func equal(value, literal interface{}) bool {
+	if s, ok := value.(string); ok {
+		l, ok := literal.(string)
+		return ok && strings.EqualFold(s, l)
+	}
+	return value == literal
+}
+
+// property returns the value of the property with the given path, nested properties are separated by /
+func property(obj Object, path string) interface{} {
+	var value interface{} = map[string]interface{}(obj)
+	for _, name := range strings.Split(path, "/") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[name]
+	}
+	return value
+}
+
+// searchPattern matches a search clause, e.g. "displayName:alice"
+var searchPattern = regexp.MustCompile(`^"?(\w+):([^"]*)"?$`)
+
+// searchObjects returns the Objects whose property contains the value of the given $search, e.g.
+// "displayName:alice". Only a single clause is supported.
+func searchObjects(objects []Object, search string) ([]Object, error) {
+	m := searchPattern.FindStringSubmatch(strings.TrimSpace(search))
+	if m == nil {
+		return nil, fmt.Errorf("Unsupported $search %q", search)
+	}
+	var res []Object
+	for _, obj := range objects {
+		if s, _ := obj[m[1]].(string); strings.Contains(strings.ToLower(s), strings.ToLower(m[2])) {
+			res = append(res, obj)
+		}
+	}
+	return res, nil
+}
+
+// sortObjects sorts the Objects by the given $orderby, e.g. "displayName desc". Only a single property is supported.
+func sortObjects(objects []Object, orderBy string) error {
+	fields := strings.Fields(orderBy)
+	if len(fields) == 0 || len(fields) > 2 || (len(fields) == 2 && fields[1] != "asc" && fields[1] != "desc") {
+		return fmt.Errorf("Unsupported $orderby %q", orderBy)
+	}
+	desc := len(fields) == 2 && fields[1] == "desc"
+	sort.SliceStable(objects, func(i, j int) bool {
+		a, b := fmt.Sprint(property(objects[i], fields[0])), fmt.Sprint(property(objects[j], fields[0]))
+		if desc {
+			return strings.ToLower(a) > strings.ToLower(b)
+		}
+		return strings.ToLower(a) < strings.ToLower(b)
+	})
+	return nil
+}
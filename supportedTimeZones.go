@@ -3,6 +3,7 @@ package msgraph
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -10,6 +11,32 @@ import (
 // and load all TimeZones form Microsoft, correlate them to IANA and set proper time.Location
 var globalSupportedTimeZones supportedTimeZones
 
+// globalSupportedTimeZonesLock synchronizes the access to globalSupportedTimeZones, because API-calls may run concurrently
+var globalSupportedTimeZonesLock sync.RWMutex
+
+// getGlobalSupportedTimeZones returns the globalSupportedTimeZones, safe for concurrent use
+func getGlobalSupportedTimeZones() supportedTimeZones {
+	globalSupportedTimeZonesLock.RLock()
+	defer globalSupportedTimeZonesLock.RUnlock()
+	return globalSupportedTimeZones
+}
+
+// loadGlobalSupportedTimeZones initializes the globalSupportedTimeZones with the supported time zones
+// of the given user, if they have not been loaded yet. Safe for concurrent use.
+func loadGlobalSupportedTimeZones(u User, opts getRequestParams) error {
+	globalSupportedTimeZonesLock.Lock()
+	defer globalSupportedTimeZonesLock.Unlock()
+	if len(globalSupportedTimeZones.Value) > 0 {
+		return nil
+	}
+	timeZones, err := u.getTimeZoneChoices(opts)
+	if err != nil {
+		return err
+	}
+	globalSupportedTimeZones = timeZones
+	return nil
+}
+
 // supportedTimeZones represents multiple instances grabbed by https://developer.microsoft.com/en-us/graph/docs/api-reference/v1.0/api/outlookuser_supportedtimezones
 type supportedTimeZones struct {
 	Value []supportedTimeZone